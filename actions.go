@@ -0,0 +1,79 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// ErrNotLoggedIn is returned by actions on a Scraper without a session, see WithSession.
+var ErrNotLoggedIn = errors.New("action requires a logged-in session")
+
+// ErrActionUnavailable is returned when an item's page doesn't offer the action with an auth token,
+// ie. because the account already voted on it or the session has expired.
+var ErrActionUnavailable = errors.New("action is not available on the item")
+
+// Upvote upvotes the post or comment as the logged-in account.
+func (s *Scraper) Upvote(ctx context.Context, id int) error {
+	return s.act(ctx, id, "vote?id="+strconv.Itoa(id)+"&how=up")
+}
+
+// Favorite adds the post or comment to the logged-in account's favorites.
+func (s *Scraper) Favorite(ctx context.Context, id int) error {
+	return s.act(ctx, id, "fave?id="+strconv.Itoa(id))
+}
+
+// Hide hides the post from the logged-in account's listings.
+func (s *Scraper) Hide(ctx context.Context, id int) error {
+	return s.act(ctx, id, "hide?id="+strconv.Itoa(id))
+}
+
+// actionLinkExpr matches every link, as the action's prefix is only known when acting
+var actionLinkExpr = xpath.MustCompile("//a[@href]")
+
+// act follows the link starting with prefix on the item's page. HackerNews only accepts actions
+// with the auth token it puts in the link for the logged-in account, so the page is scraped for it first.
+// Actions count against the request budget and wait for the rate limit like any other request.
+func (s *Scraper) act(ctx context.Context, id int, prefix string) error {
+	if s.session == "" {
+		return ErrNotLoggedIn
+	}
+	if id < 1 {
+		return errors.New("item id must be a positive integer")
+	}
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	doc, err := s.fetch(ctx, s.baseURL+"item?id="+strconv.Itoa(id))
+	if err != nil {
+		return err
+	}
+	if isNoprocrast(doc) {
+		return ErrNoprocrast
+	}
+
+	var href string
+	for _, link := range htmlquery.QuerySelectorAll(doc, actionLinkExpr) {
+		if h := htmlquery.SelectAttr(link, "href"); h == prefix || strings.HasPrefix(h, prefix+"&") {
+			href = h
+			break
+		}
+	}
+	if href == "" {
+		return ErrActionUnavailable
+	}
+	if u, err := url.Parse(href); err != nil || u.IsAbs() || u.Query().Get("auth") == "" {
+		return ErrActionUnavailable
+	}
+
+	return s.fetchBody(ctx, s.baseURL+href, true, func(r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+}
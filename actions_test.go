@@ -0,0 +1,54 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestActions(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/item.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	// Logged in, the vote and hide links carry auth tokens too
+	page := strings.NewReplacer(
+		"vote?id=28719320&amp;how=up&amp;goto", "vote?id=28719320&amp;how=up&amp;auth=voteauth&amp;goto",
+		`<span id="unv_28719320"></span> |`, `<span id="unv_28719320"></span> | <a href="hide?id=28719320&amp;auth=hideauth&amp;goto=news">hide</a> |`,
+	).Replace(string(fixture))
+
+	var mu sync.Mutex
+	var actions []string
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/item" {
+			w.Write([]byte(page))
+			return
+		}
+		mu.Lock()
+		actions = append(actions, r.URL.Path+" "+r.URL.Query().Get("auth"))
+		mu.Unlock()
+	}, WithSession("alice&secret"))
+
+	ctx := context.Background()
+	for _, act := range []func(context.Context, int) error{s.Upvote, s.Favorite, s.Hide} {
+		if err := act(ctx, 28719320); err != nil {
+			t.Error("error: ", err)
+		}
+	}
+	if got := strings.Join(actions, ", "); got != "/vote voteauth, /fave faveauth, /hide hideauth" {
+		t.Error("made actions ", got)
+	}
+
+	// The comments' vote links have no token
+	if err := s.Upvote(ctx, 28719401); !errors.Is(err, ErrActionUnavailable) {
+		t.Error("returned ", err, " instead of ErrActionUnavailable")
+	}
+	if err := NewScraper().Upvote(ctx, 28719320); !errors.Is(err, ErrNotLoggedIn) {
+		t.Error("returned ", err, " instead of ErrNotLoggedIn")
+	}
+}
@@ -0,0 +1,180 @@
+package hnscraper
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// Archived pages are named after when they were retrieved and the escaped path they were retrieved from,
+// ie. "20211016T120000.000000000Z_news%3Fp%3D1.html", with a ".gz" suffix when compressed by WithArchiveGzip
+const archiveTimeLayout = "20060102T150405.000000000Z"
+
+// An ArchiveEntry is a page saved by WithArchiveDir.
+type ArchiveEntry struct {
+	Path      string    // Where the HTML is saved
+	Page      string    // The path and query the page was retrieved from, ie. "news?p=1"
+	Retrieved time.Time // When the page was retrieved
+}
+
+// archive saves the raw HTML of a page to the archive directory, or hands it to the archive function.
+func (s *Scraper) archive(pageURL string, retrieved time.Time, body []byte) error {
+	page := strings.TrimPrefix(pageURL, s.baseURL)
+	name := retrieved.UTC().Format(archiveTimeLayout) + "_" + url.QueryEscape(page) + ".html"
+	if s.archiveFunc != nil {
+		return s.archiveFunc(name, body)
+	}
+
+	if err := os.MkdirAll(s.archiveDir, 0o755); err != nil {
+		return err
+	}
+	if !s.archiveGzip {
+		return os.WriteFile(filepath.Join(s.archiveDir, name), body, 0o644)
+	}
+
+	file, err := os.Create(filepath.Join(s.archiveDir, name+".gz"))
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(file)
+	if _, err := zw.Write(body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ReadArchive lists the pages saved in an archive directory, oldest first.
+// Files that weren't saved by WithArchiveDir are ignored. Compressed and uncompressed pages can be mixed.
+func ReadArchive(dir string) ([]ArchiveEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ArchiveEntry
+	for _, file := range files {
+		name := file.Name()
+		base := strings.TrimSuffix(name, ".gz")
+		if file.IsDir() || !strings.HasSuffix(base, ".html") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(base, ".html"), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		retrieved, err := time.Parse(archiveTimeLayout, parts[0])
+		if err != nil {
+			continue
+		}
+		page, err := url.QueryUnescape(parts[1])
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, ArchiveEntry{Path: filepath.Join(dir, name), Page: page, Retrieved: retrieved})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Retrieved.Before(entries[j].Retrieved)
+	})
+
+	return entries, nil
+}
+
+// ParseArchived parses an archived listing page as if it had just been scraped,
+// using the time it was retrieved as the page's Retrieved time.
+func (s *Scraper) ParseArchived(entry ArchiveEntry) (Page, error) {
+	return s.ParseArchivedContext(context.Background(), entry)
+}
+
+// ParseArchivedContext parses an archived listing page like ParseArchived, failing with ctx's error if it is done,
+// and tracing the parse under ctx.
+func (s *Scraper) ParseArchivedContext(ctx context.Context, entry ArchiveEntry) (Page, error) {
+	var page Page
+
+	if err := ctx.Err(); err != nil {
+		return page, err
+	}
+
+	pageURL, err := url.Parse(entry.Page)
+	if err != nil {
+		return page, err
+	}
+	if pageURL.Path != "news" {
+		return page, errors.New("archived page is not a listing: " + entry.Page)
+	}
+	pageNum := 1
+	if p := pageURL.Query().Get("p"); p != "" {
+		if pageNum, err = strconv.Atoi(p); err != nil {
+			return page, err
+		}
+	}
+
+	file, err := os.Open(entry.Path)
+	if err != nil {
+		return page, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(entry.Path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return page, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	doc, err := htmlquery.Parse(r)
+	if err != nil {
+		return page, err
+	}
+
+	return s.parsePage(ctx, doc, pageNum, entry.Retrieved.In(s.location))
+}
+
+// ReplayArchive parses every listing page in an archive directory, oldest first, as if they had just been scraped.
+// Other archived pages, such as item pages, are skipped.
+func ReplayArchive(dir string) ([]Page, error) {
+	return defaultScraper.ReplayArchive(dir)
+}
+
+// ReplayArchive parses every listing page in an archive directory, oldest first, as if they had just been scraped.
+// Other archived pages, such as item pages, are skipped.
+func (s *Scraper) ReplayArchive(dir string) ([]Page, error) {
+	entries, err := ReadArchive(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Page
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Page, "news") {
+			continue
+		}
+		page, err := s.ParseArchived(entry)
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
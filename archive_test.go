@@ -0,0 +1,103 @@
+package hnscraper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestArchiveReplay(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/item" {
+			http.ServeFile(w, r, "testdata/item.html")
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}, WithArchiveDir(dir))
+
+	scraped, err := s.ScrapeMultPages(1, 2)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if _, err := s.ScrapeItem(28719320); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	entries, err := ReadArchive(dir)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(entries) != 3 || entries[0].Page != "news?p=1" || entries[2].Page != "item?id=28719320" {
+		t.Fatal("archived incorrect entries: ", entries)
+	}
+
+	replayed, err := NewScraper().ReplayArchive(dir)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(replayed) != 2 || replayed[1].Num != 2 || !replayed[0].Retrieved.Equal(entries[0].Retrieved) {
+		t.Fatal("replayed incorrect pages")
+	}
+	for i, post := range replayed[0].Posts {
+		if changed := post.Changed(scraped[0].Posts[i]); len(changed) != 0 {
+			t.Error("replayed post differs in ", changed)
+		}
+	}
+}
+
+func TestArchiveFunc(t *testing.T) {
+	var names []string
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithArchiveFunc(func(name string, body []byte) error {
+		if len(body) == 0 {
+			t.Error("archived empty page ", name)
+		}
+		names = append(names, name)
+		return nil
+	}))
+
+	if _, err := s.ScrapePage(3); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(names) != 1 || !strings.HasSuffix(names[0], "_news%3Fp%3D3.html") {
+		t.Error("archived pages named ", names)
+	}
+
+	// Pages scraped while logged in hold the account's auth tokens
+	names = nil
+	s = newTestScraper(t, serveFile("testdata/news.html"), WithSession("alice&secret"), WithArchiveFunc(func(name string, body []byte) error {
+		names = append(names, name)
+		return nil
+	}))
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(names) != 0 {
+		t.Error("archived pages scraped with a session: ", names)
+	}
+}
+
+func TestArchiveGzip(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithArchiveDir(dir), WithArchiveGzip())
+	scraped, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	entries, err := ReadArchive(dir)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Path, ".html.gz") || entries[0].Page != "news?p=1" {
+		t.Fatal("archived incorrect entries: ", entries)
+	}
+
+	replayed, err := s.ParseArchived(entries[0])
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(replayed.Posts) != len(scraped.Posts) || !replayed.Posts[0].Equal(scraped.Posts[0]) {
+		t.Error("replayed compressed page differently")
+	}
+}
@@ -0,0 +1,123 @@
+package hnscraper
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of making a request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open after repeated failures")
+
+// A BreakerState is the state of a Scraper's circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests are made normally
+	BreakerOpen                         // Requests fail immediately until the cooldown has passed
+	BreakerHalfOpen                     // The cooldown has passed and the next request decides whether to close or reopen
+)
+
+func (b BreakerState) String() string {
+	switch b {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+type breaker struct {
+	threshold int                         // Consecutive failures that open the circuit
+	cooldown  time.Duration               // How long the circuit stays open
+	onChange  func(from, to BreakerState) // Called on every transition
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // Whether the half-open circuit's trial request is in flight
+}
+
+// allow reports whether a request may be made, moving an open circuit to half-open once its cooldown has passed.
+// A half-open circuit lets a single trial request through, refusing the rest until its outcome is recorded or released.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(BreakerHalfOpen)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+// release ends an allowed request that says nothing about HackerNews' health, ie. one the caller cancelled,
+// letting another trial request through if the circuit is half-open.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// record updates the circuit with the outcome of a request.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.transition(BreakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.transition(BreakerOpen)
+	}
+}
+
+func (b *breaker) transition(to BreakerState) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
+
+func (b *breaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// BreakerState returns the current state of the circuit breaker.
+// Scrapers without a circuit breaker are always closed.
+func (s *Scraper) BreakerState() BreakerState {
+	if s.breaker == nil {
+		return BreakerClosed
+	}
+
+	return s.breaker.current()
+}
@@ -0,0 +1,105 @@
+package hnscraper
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var logs bytes.Buffer
+	var requests, healthy int32
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			http.Error(w, "Sorry.", http.StatusServiceUnavailable)
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}, WithCircuitBreaker(2, 50*time.Millisecond), WithLogger(log.New(&logs, "", 0)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.ScrapePage(1); !errors.Is(err, ErrThrottled) {
+			t.Error("returned ", err, " instead of ErrThrottled")
+		}
+	}
+	if s.BreakerState() != BreakerOpen {
+		t.Error("circuit is ", s.BreakerState(), " after repeated failures")
+	}
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrCircuitOpen) || atomic.LoadInt32(&requests) != 2 {
+		t.Error("made a request while the circuit was open")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Error("error: ", err)
+	}
+	if s.BreakerState() != BreakerClosed {
+		t.Error("circuit is ", s.BreakerState(), " after recovering")
+	}
+
+	want := "circuit breaker closed -> open\nhnscraper: circuit breaker open -> half-open\nhnscraper: circuit breaker half-open -> closed"
+	if !strings.Contains(logs.String(), want) {
+		t.Error("logged transitions incorrectly: ", logs.String())
+	}
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	var requests int32
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 {
+			arrived <- struct{}{}
+			<-release
+		}
+		http.Error(w, "Sorry.", http.StatusServiceUnavailable)
+	}, WithCircuitBreaker(1, 20*time.Millisecond))
+
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrThrottled) {
+		t.Fatal("returned ", err, " instead of ErrThrottled")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// While the trial request is in flight, the circuit refuses everyone else
+	trial := make(chan error, 1)
+	go func() {
+		_, err := s.ScrapePage(1)
+		trial <- err
+	}()
+	<-arrived
+	for i := 0; i < 3; i++ {
+		if _, err := s.ScrapePage(1); !errors.Is(err, ErrCircuitOpen) {
+			t.Error("returned ", err, " instead of ErrCircuitOpen during the trial")
+		}
+	}
+	close(release)
+	if err := <-trial; !errors.Is(err, ErrThrottled) {
+		t.Error("trial returned ", err)
+	}
+	if s.BreakerState() != BreakerOpen || atomic.LoadInt32(&requests) != 2 {
+		t.Error("circuit is ", s.BreakerState(), " after ", atomic.LoadInt32(&requests), " requests")
+	}
+}
+
+func TestCircuitBreakerLocalRefusal(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithCircuitBreaker(1, time.Minute), WithMaxRequests(1))
+
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Fatal("error: ", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.ScrapePage(1); !errors.Is(err, ErrBudgetExceeded) {
+			t.Error("returned ", err, " instead of ErrBudgetExceeded")
+		}
+	}
+	if s.BreakerState() != BreakerClosed {
+		t.Error("circuit is ", s.BreakerState(), " after the budget ran out")
+	}
+}
@@ -0,0 +1,162 @@
+// Package bundle packs scraped pages into a single self-describing archive for exchanging HackerNews datasets:
+// a gzipped tar holding the pages as newline-delimited JSON and a manifest describing them.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+// Format identifies the layout of a bundle, changing if the layout ever does.
+const Format = "hnscraper-bundle/1"
+
+// The files in a bundle, in the order they are written.
+const (
+	ManifestFile = "manifest.json" // The Manifest, as JSON
+	PagesFile    = "pages.ndjson"  // Every page, one JSON object per line, oldest first
+)
+
+// A Manifest describes the pages in a bundle.
+type Manifest struct {
+	Format          string      `json:"format"`           // The bundle's layout, see Format
+	From            time.Time   `json:"from"`             // The start of the period, inclusive
+	To              time.Time   `json:"to"`               // The end of the period, exclusive
+	Created         time.Time   `json:"created"`          // When the bundle was made
+	Pages           int         `json:"pages"`            // How many page snapshots there are
+	Posts           int         `json:"posts"`            // How many posts there are across the snapshots
+	PageCounts      map[int]int `json:"page_counts"`      // How many snapshots there are of each page number
+	ScraperVersion  string      `json:"scraper_version"`  // The hnscraper.Version the bundle was made with
+	SelectorVersion int         `json:"selector_version"` // The hnscraper.SelectorVersion the pages were parsed with
+}
+
+// Write bundles the pages retrieved within [from, to) to w, in retrieval order. Pages outside the period are left out.
+func Write(w io.Writer, pages []hnscraper.Page, from, to time.Time) error {
+	var kept []hnscraper.Page
+	for _, page := range pages {
+		if !page.Retrieved.Before(from) && page.Retrieved.Before(to) {
+			kept = append(kept, page)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Retrieved.Before(kept[j].Retrieved)
+	})
+
+	manifest := Manifest{
+		Format:          Format,
+		From:            from,
+		To:              to,
+		Created:         time.Now().UTC(),
+		Pages:           len(kept),
+		PageCounts:      make(map[int]int),
+		ScraperVersion:  hnscraper.Version,
+		SelectorVersion: hnscraper.SelectorVersion,
+	}
+	var lines bytes.Buffer
+	enc := json.NewEncoder(&lines)
+	for _, page := range kept {
+		manifest.Posts += len(page.Posts)
+		manifest.PageCounts[page.Num]++
+		if err := enc.Encode(page); err != nil {
+			return err
+		}
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{ManifestFile, manifestData}, {PagesFile, lines.Bytes()}} {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), ModTime: manifest.Created}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Export bundles the snapshots a store holds from within [from, to) to w, ie. a day or week of scraping.
+func Export(w io.Writer, st store.Store, from, to time.Time) error {
+	pages, err := st.Snapshots(from, to)
+	if err != nil {
+		return err
+	}
+
+	return Write(w, pages, from, to)
+}
+
+// Read reads a bundle written by Write, returning its manifest and pages.
+// Bundles of an unknown format, or whose pages don't match their manifest, are rejected.
+func Read(r io.Reader) (Manifest, []hnscraper.Page, error) {
+	var manifest Manifest
+	var pages []hnscraper.Page
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, nil, err
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	seen := make(map[string]bool)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return manifest, nil, err
+		}
+		seen[header.Name] = true
+
+		switch header.Name {
+		case ManifestFile:
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return manifest, nil, fmt.Errorf("%s: %w", ManifestFile, err)
+			}
+			if manifest.Format != Format {
+				return manifest, nil, fmt.Errorf("unknown bundle format %q", manifest.Format)
+			}
+		case PagesFile:
+			dec := json.NewDecoder(tr)
+			for {
+				var page hnscraper.Page
+				if err := dec.Decode(&page); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					return manifest, nil, fmt.Errorf("%s: %w", PagesFile, err)
+				}
+				pages = append(pages, page)
+			}
+		}
+	}
+
+	if !seen[ManifestFile] || !seen[PagesFile] {
+		return manifest, nil, errors.New("bundle is missing its manifest or pages")
+	}
+	if len(pages) != manifest.Pages {
+		return manifest, nil, fmt.Errorf("bundle holds %d pages, but its manifest lists %d", len(pages), manifest.Pages)
+	}
+
+	return manifest, pages, nil
+}
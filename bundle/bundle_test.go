@@ -0,0 +1,54 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+var day = time.Date(2021, 10, 16, 0, 0, 0, 0, time.UTC)
+
+func TestExport(t *testing.T) {
+	st := store.NewMemory()
+	pages := []hnscraper.Page{
+		{Num: 1, Retrieved: day.Add(13 * time.Hour), Posts: []hnscraper.Post{{ID: 1, Score: 20}, {ID: 2, Score: 3}}},
+		{Num: 1, Retrieved: day.Add(12 * time.Hour), Posts: []hnscraper.Post{{ID: 1, Score: 10}}},
+		{Num: 2, Retrieved: day.Add(12 * time.Hour), Posts: []hnscraper.Post{{ID: 3, Score: 1}}},
+		// The next day is left out
+		{Num: 1, Retrieved: day.Add(25 * time.Hour), Posts: []hnscraper.Post{{ID: 4}}},
+	}
+	for _, page := range pages {
+		if err := st.PutPage(page); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, st, day, day.Add(24*time.Hour)); err != nil {
+		t.Fatal("error: ", err)
+	}
+	manifest, read, err := Read(&buf)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	if manifest.Pages != 3 || manifest.Posts != 4 || manifest.PageCounts[1] != 2 || manifest.PageCounts[2] != 1 {
+		t.Errorf("wrote manifest %+v", manifest)
+	}
+	if manifest.ScraperVersion != hnscraper.Version || manifest.SelectorVersion != hnscraper.SelectorVersion ||
+		!manifest.From.Equal(day) || !manifest.To.Equal(day.Add(24*time.Hour)) {
+		t.Errorf("described the bundle incorrectly: %+v", manifest)
+	}
+	if len(read) != 3 || !read[0].Retrieved.Equal(day.Add(12*time.Hour)) || read[2].Posts[0].Score != 20 {
+		t.Error("read back pages incorrectly")
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, _, err := Read(bytes.NewReader([]byte("not a bundle"))); err == nil {
+		t.Error("read a file that isn't a bundle")
+	}
+}
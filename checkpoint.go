@@ -0,0 +1,115 @@
+package hnscraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// A Checkpoint is the progress of a resumable multi-page scrape, as persisted to its state file.
+type Checkpoint struct {
+	StartPage int             `json:"start_page"` // The first page of the scrape
+	EndPage   int             `json:"end_page"`   // The last page of the scrape
+	LastPage  int             `json:"last_page"`  // The last page that was scraped successfully, 0 if none were
+	SeenKeys  map[string]bool `json:"seen_keys"`  // The Post.Key of every post scraped so far
+}
+
+// LoadCheckpoint reads the checkpoint from a state file written by ResumeMultPages.
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return checkpoint, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, err
+	}
+
+	if checkpoint.SeenKeys == nil {
+		checkpoint.SeenKeys = make(map[string]bool)
+	}
+
+	return checkpoint, nil
+}
+
+// save writes the checkpoint to a temporary file first so an interruption can't leave a corrupt state file.
+func (c Checkpoint) save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// ResumeMultPages scrapes pages like ScrapeMultPages, persisting its progress to the state file after every page.
+// If the state file holds the progress of an interrupted scrape of the same range, scraping continues after
+// the last completed page and posts that were already scraped are left out.
+// Only the pages scraped by this call are returned. The state file is removed once every page has been scraped.
+func ResumeMultPages(statePath string, startPage, endPage int) ([]Page, error) {
+	return defaultScraper.ResumeMultPages(statePath, startPage, endPage)
+}
+
+// ResumeMultPages scrapes pages like ScrapeMultPages, persisting its progress to the state file after every page.
+// If the state file holds the progress of an interrupted scrape of the same range, scraping continues after
+// the last completed page and posts that were already scraped are left out.
+// Only the pages scraped by this call are returned. The state file is removed once every page has been scraped.
+func (s *Scraper) ResumeMultPages(statePath string, startPage, endPage int) ([]Page, error) {
+	var pages []Page
+
+	if startPage < 1 || endPage < 1 {
+		return pages, errors.New("page numbers must be positive integers")
+	} else if startPage > endPage {
+		return pages, errors.New(
+			"starting page number cannot be larger than ending page number")
+	}
+
+	// A state file that can't be read is reported rather than silently starting over
+	checkpoint, err := LoadCheckpoint(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return pages, err
+	}
+	if err != nil || checkpoint.StartPage != startPage || checkpoint.EndPage != endPage {
+		// Nothing to resume, or the state is from a different scrape
+		checkpoint = Checkpoint{StartPage: startPage, EndPage: endPage, SeenKeys: make(map[string]bool)}
+	}
+
+	firstPage := startPage
+	if checkpoint.LastPage >= startPage {
+		firstPage = checkpoint.LastPage + 1
+	}
+
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	for i := firstPage; i <= endPage; i++ {
+		page, err := s.scrapePage(ctx, i)
+		if err != nil {
+			return pages, &PagesError{Page: i, Err: err}
+		}
+
+		var unseen []Post
+		for _, post := range page.Posts {
+			if key := post.Key(); !checkpoint.SeenKeys[key] {
+				checkpoint.SeenKeys[key] = true
+				unseen = append(unseen, post)
+			}
+		}
+		page.Posts = unseen
+		pages = append(pages, page)
+
+		checkpoint.LastPage = i
+		if err := checkpoint.save(statePath); err != nil {
+			return pages, err
+		}
+	}
+
+	return pages, os.Remove(statePath)
+}
@@ -0,0 +1,86 @@
+package hnscraper
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResumeMultPages(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	failPage := "3"
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		page := r.URL.Query().Get("p")
+		requests[page]++
+		if page == failPage {
+			// Drop the connection to interrupt the scrape
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	})
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	pages, err := s.ResumeMultPages(statePath, 1, 4)
+	if err == nil {
+		t.Error("did not report the failing page")
+		return
+	}
+	if len(pages) != 2 || len(pages[0].Posts) != 3 || len(pages[1].Posts) != 0 {
+		t.Error("returned ", len(pages), " pages before the failure instead of 2")
+	}
+
+	checkpoint, err := LoadCheckpoint(statePath)
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if checkpoint.LastPage != 2 || len(checkpoint.SeenKeys) != 3 {
+		t.Errorf("saved incorrect checkpoint: %+v", checkpoint)
+	}
+
+	mu.Lock()
+	failPage = ""
+	mu.Unlock()
+	pages, err = s.ResumeMultPages(statePath, 1, 4)
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if len(pages) != 2 || pages[0].Num != 3 || len(pages[0].Posts) != 0 {
+		t.Error("did not resume after the last completed page")
+	}
+	mu.Lock()
+	if requests["1"] != 1 || requests["2"] != 1 {
+		t.Error("scraped completed pages again: ", requests)
+	}
+	mu.Unlock()
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Error("did not remove the state file after finishing")
+	}
+}
+
+func TestResumeMultPagesBadState(t *testing.T) {
+	var requests int32
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(w, r, "testdata/news.html")
+	})
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(statePath, []byte(`{"start_page": 1, "end_`), 0o644); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	if _, err := s.ResumeMultPages(statePath, 1, 2); err == nil {
+		t.Error("started over from a corrupt state file")
+	}
+	if atomic.LoadInt32(&requests) != 0 {
+		t.Error("scraped despite the corrupt state file")
+	}
+}
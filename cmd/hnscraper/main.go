@@ -0,0 +1,199 @@
+// Command hnscraper works with scraped HackerNews data from the shell.
+//
+// Usage:
+//
+//	hnscraper diff [-json] old.json new.json
+//
+// diff compares two saved snapshots and prints the posts that are new, removed, or changed, with score deltas
+// and rank moves. A snapshot is a JSON encoded hnscraper.Page, a list of pages, or a list of posts.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "hnscraper:", err)
+		os.Exit(1)
+	}
+}
+
+const usage = "usage: hnscraper diff [-json] old.json new.json"
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "diff":
+		return runDiff(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+// A Diff is how the posts differ between two snapshots.
+type Diff struct {
+	New     []hnscraper.Post `json:"new"`     // Posts only in the new snapshot
+	Removed []hnscraper.Post `json:"removed"` // Posts only in the old snapshot
+	Changed []Change         `json:"changed"` // Posts in both that differ
+}
+
+// A Change is a post that differs between two snapshots.
+type Change struct {
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	Fields      []string `json:"fields"` // The names of the fields that differ, see hnscraper.Post.Changed
+	OldRank     int      `json:"old_rank"`
+	NewRank     int      `json:"new_rank"`
+	OldScore    int      `json:"old_score"`
+	NewScore    int      `json:"new_score"`
+	OldComments int      `json:"old_comments"`
+	NewComments int      `json:"new_comments"`
+}
+
+func runDiff(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the differences as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New(usage)
+	}
+
+	before, err := loadSnapshot(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadSnapshot(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	diff := diffPages(before, after)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+
+	return printDiff(stdout, diff)
+}
+
+// loadSnapshot reads a saved page, list of pages, or list of posts, merging them into a single page.
+func loadSnapshot(path string) (hnscraper.Page, error) {
+	var merged hnscraper.Page
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return merged, err
+	}
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		err = json.Unmarshal(data, &merged)
+	case bytes.HasPrefix(data, []byte("[")):
+		// Lists of pages and lists of posts are told apart by whether their elements hold posts
+		var elements []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return merged, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(elements) > 0 && elements[0]["Posts"] != nil {
+			var pages []hnscraper.Page
+			err = json.Unmarshal(data, &pages)
+			for _, page := range pages {
+				merged.Posts = append(merged.Posts, page.Posts...)
+			}
+		} else {
+			err = json.Unmarshal(data, &merged.Posts)
+		}
+	default:
+		err = errors.New("not a JSON snapshot")
+	}
+	if err != nil {
+		return merged, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return merged, nil
+}
+
+// diffPages compares two snapshots, listing changed posts in the new snapshot's order.
+func diffPages(before, after hnscraper.Page) Diff {
+	diff := Diff{New: after.NewSince(before), Removed: after.Dropped(before)}
+
+	old := make(map[string]hnscraper.Post, len(before.Posts))
+	for _, post := range before.Posts {
+		old[post.Key()] = post
+	}
+	for _, post := range after.Posts {
+		prev, ok := old[post.Key()]
+		if !ok {
+			continue
+		}
+		if fields := post.Changed(prev); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, Change{
+				ID:          post.ID,
+				Title:       post.Title,
+				Fields:      fields,
+				OldRank:     prev.Rank,
+				NewRank:     post.Rank,
+				OldScore:    prev.Score,
+				NewScore:    post.Score,
+				OldComments: prev.NumComments,
+				NewComments: post.NumComments,
+			})
+		}
+	}
+
+	return diff
+}
+
+// printDiff prints the differences as a table.
+func printDiff(w io.Writer, diff Diff) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tID\tRANK\tSCORE\tCOMMENTS\tTITLE")
+	for _, post := range diff.New {
+		fmt.Fprintf(tw, "new\t%d\t%d\t%d\t%d\t%s\n", post.ID, post.Rank, post.Score, post.NumComments, post.Title)
+	}
+	for _, post := range diff.Removed {
+		fmt.Fprintf(tw, "removed\t%d\t%d\t%d\t%d\t%s\n", post.ID, post.Rank, post.Score, post.NumComments, post.Title)
+	}
+	for _, c := range diff.Changed {
+		fmt.Fprintf(tw, "changed\t%d\t%s\t%s\t%s\t%s\n", c.ID, move(c.OldRank, c.NewRank),
+			delta(c.OldScore, c.NewScore), delta(c.OldComments, c.NewComments), c.Title)
+	}
+
+	return tw.Flush()
+}
+
+// move renders a rank change, ie. "5 -> 2".
+func move(from, to int) string {
+	if from == to {
+		return strconv.Itoa(to)
+	}
+
+	return fmt.Sprintf("%d -> %d", from, to)
+}
+
+// delta renders a count and how it changed, ie. "120 (+15)".
+func delta(from, to int) string {
+	if from == to {
+		return strconv.Itoa(to)
+	}
+
+	return fmt.Sprintf("%d (%+d)", to, to-from)
+}
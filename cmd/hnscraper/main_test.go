@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+func writeSnapshot(t *testing.T, name string, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	return path
+}
+
+func diffSnapshots(t *testing.T) (string, string) {
+	before := hnscraper.Page{Num: 1, Posts: []hnscraper.Post{
+		{ID: 1, Rank: 1, Title: "Stays put", Score: 100},
+		{ID: 2, Rank: 2, Title: "Climbs", Score: 50, NumComments: 3},
+		{ID: 3, Rank: 3, Title: "Falls off", Score: 10},
+	}}
+	after := []hnscraper.Page{{Num: 1, Posts: []hnscraper.Post{
+		{ID: 2, Rank: 1, Title: "Climbs", Score: 70, NumComments: 8},
+		{ID: 1, Rank: 2, Title: "Stays put", Score: 100},
+	}}, {Num: 2, Posts: []hnscraper.Post{
+		{ID: 4, Rank: 31, Title: "Arrives", Score: 5},
+	}}}
+
+	return writeSnapshot(t, "old.json", before), writeSnapshot(t, "new.json", after)
+}
+
+func TestDiffTable(t *testing.T) {
+	before, after := diffSnapshots(t)
+
+	var out bytes.Buffer
+	if err := run([]string{"diff", before, after}, &out); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	for _, want := range []string{"new", "Arrives", "removed", "Falls off", "2 -> 1", "70 (+20)", "8 (+5)", "1 -> 2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	before, after := diffSnapshots(t)
+
+	var out bytes.Buffer
+	if err := run([]string{"diff", "-json", before, after}, &out); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	var diff Diff
+	if err := json.Unmarshal(out.Bytes(), &diff); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(diff.New) != 1 || diff.New[0].ID != 4 {
+		t.Error("wrong new posts: ", diff.New)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != 3 {
+		t.Error("wrong removed posts: ", diff.Removed)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatal("wrong changed posts: ", diff.Changed)
+	}
+	climbed := diff.Changed[0]
+	if climbed.ID != 2 || climbed.OldRank != 2 || climbed.NewRank != 1 || climbed.OldScore != 50 || climbed.NewScore != 70 {
+		t.Error("wrong change: ", climbed)
+	}
+}
+
+func TestDiffPosts(t *testing.T) {
+	posts := []hnscraper.Post{{ID: 1, Rank: 1, Title: "Same"}}
+	before := writeSnapshot(t, "old.json", posts)
+	after := writeSnapshot(t, "new.json", posts)
+
+	var out bytes.Buffer
+	if err := run([]string{"diff", "-json", before, after}, &out); err != nil {
+		t.Fatal("error: ", err)
+	}
+	var diff Diff
+	if err := json.Unmarshal(out.Bytes(), &diff); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(diff.New) != 0 || len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+		t.Error("identical snapshots differ: ", diff)
+	}
+}
+
+func TestDiffUsage(t *testing.T) {
+	if err := run(nil, &bytes.Buffer{}); err == nil {
+		t.Error("no command didn't error")
+	}
+	if err := run([]string{"diff", "one.json"}, &bytes.Buffer{}); err == nil {
+		t.Error("missing snapshot didn't error")
+	}
+	if err := run([]string{"merge"}, &bytes.Buffer{}); err == nil {
+		t.Error("unknown command didn't error")
+	}
+}
@@ -0,0 +1,95 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithCoalescing makes concurrent scrapes of the same listing page share a single request to HackerNews,
+// and lets scrapes starting within window of one finishing reuse its page rather than fetching it again.
+// A window of 0 only shares requests that overlap. Failures are shared with the scrapes waiting on them, but never reused.
+// This keeps a burst of callers, ie. clients of a server, from turning into a burst of requests.
+func WithCoalescing(window time.Duration) Option {
+	return func(s *Scraper) {
+		s.coalescer = &coalescer{window: window, calls: make(map[int]*pageCall)}
+	}
+}
+
+// A coalescer shares the scrapes of each page number between callers.
+type coalescer struct {
+	window time.Duration // How long a finished scrape is reused for
+
+	mu    sync.Mutex
+	calls map[int]*pageCall // The in-flight or reusable scrape of each page number
+}
+
+// A pageCall is a single scrape of a page, shared by everyone asking for it.
+type pageCall struct {
+	done     chan struct{} // Closed once the scrape has finished
+	page     Page
+	err      error
+	finished time.Time
+}
+
+// do scrapes the page number with scrape, unless a scrape of it is already in flight or finished within the window,
+// in which case its result is returned instead. Every caller gets its own copy of the posts.
+func (c *coalescer) do(ctx context.Context, pageNum int, scrape func(context.Context, int) (Page, error)) (Page, error) {
+	for {
+		c.mu.Lock()
+		call, ok := c.calls[pageNum]
+		if ok {
+			select {
+			case <-call.done:
+				// Finished calls are only left in the map to be reused, but may have outlived their window
+				if time.Since(call.finished) > c.window {
+					delete(c.calls, pageNum)
+					ok = false
+				}
+			default:
+			}
+		}
+		if !ok {
+			call = &pageCall{done: make(chan struct{})}
+			c.calls[pageNum] = call
+			c.mu.Unlock()
+			c.run(ctx, pageNum, call, scrape)
+			return call.result()
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Page{}, ctx.Err()
+		case <-call.done:
+		}
+		// The scrape was cut short by its own caller giving up, which says nothing about the page, so try again
+		if (errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+
+		return call.result()
+	}
+}
+
+// run makes the call's scrape and hands its result to everyone waiting.
+func (c *coalescer) run(ctx context.Context, pageNum int, call *pageCall, scrape func(context.Context, int) (Page, error)) {
+	call.page, call.err = scrape(ctx, pageNum)
+	call.finished = time.Now()
+
+	c.mu.Lock()
+	if (call.err != nil || c.window <= 0) && c.calls[pageNum] == call {
+		delete(c.calls, pageNum)
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// result returns the call's page with a copy of its posts, so callers can't change each other's pages.
+func (call *pageCall) result() (Page, error) {
+	page := call.page
+	page.Posts = append([]Post(nil), page.Posts...)
+
+	return page, call.err
+}
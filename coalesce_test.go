@@ -0,0 +1,103 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescing(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		http.ServeFile(w, r, "testdata/news.html")
+	}
+	s := newTestScraper(t, handler, WithCoalescing(50*time.Millisecond))
+
+	var wg sync.WaitGroup
+	pages := make([]Page, 10)
+	errs := make([]error, 10)
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pages[i], errs[i] = s.ScrapePage(1)
+		}(i)
+	}
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatal("error: ", err)
+		}
+		if len(pages[i].Posts) == 0 || !pages[i].Posts[0].Equal(pages[0].Posts[0]) {
+			t.Fatal("caller ", i, " got a different page")
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Error("made ", n, " requests for concurrent scrapes instead of 1")
+	}
+
+	// Each caller owns its posts
+	pages[0].Posts[0].Title = "changed"
+	if pages[1].Posts[0].Title == "changed" {
+		t.Error("callers share their posts")
+	}
+
+	// Within the window the page is reused, after it a new request is made
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Error("made ", n, " requests within the window instead of 1")
+	}
+	if _, err := s.ScrapePage(2); err != nil {
+		t.Fatal("error: ", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Error("made ", n, " requests instead of 3 for another page and an expired one")
+	}
+}
+
+func TestCoalescingCancelledLeader(t *testing.T) {
+	var requests int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}
+	s := newTestScraper(t, handler, WithCoalescing(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	leader := make(chan error, 1)
+	go func() {
+		_, err := s.scrapePage(ctx, 1)
+		leader <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	// The follower carries on with its own request once the leader gives up
+	page, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(page.Posts) == 0 {
+		t.Error("follower got an empty page")
+	}
+	if err := <-leader; err == nil {
+		t.Error("leader didn't time out")
+	}
+}
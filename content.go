@@ -0,0 +1,200 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// ErrNotArticle is returned for links that don't lead to an HTML page, such as PDFs and images.
+var ErrNotArticle = errors.New("link is not an html page")
+
+// PostContent is the readable article a post links to.
+type PostContent struct {
+	Post      Post   // The post that links to the article
+	Title     string // The article's own title, which often differs from the post title
+	Byline    string // The article's author, if it names one
+	Text      string // The article's main text, with paragraphs separated by blank lines
+	WordCount int    // How many words Text contains
+	Err       error  // Why the article could not be extracted
+}
+
+// A ContentExtractor fetches the articles posts link to and extracts their readable text.
+// The zero value is ready to use with conservative defaults.
+type ContentExtractor struct {
+	Client      *http.Client  // The client to fetch articles with, nil for a default client
+	Timeout     time.Duration // How long each article may take, 0 for 30 seconds
+	Concurrency int           // How many articles are fetched at once, 0 for 4
+	Interval    time.Duration // The minimum time between starting fetches, 0 for no limit
+	MaxBytes    int64         // The most of each article that is read, 0 for 5 MB
+}
+
+// Extract fetches the article of every post, returning the results in the same order as the posts.
+// Posts without an external link, such as Ask HN, are returned with empty content.
+func (e *ContentExtractor) Extract(ctx context.Context, posts []Post) []PostContent {
+	client := e.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	concurrency := e.Concurrency
+	if concurrency == 0 {
+		concurrency = 4
+	}
+	maxBytes := e.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = 5 << 20
+	}
+
+	results := make([]PostContent, len(posts))
+	var external []int
+	for i, post := range posts {
+		results[i] = PostContent{Post: post}
+		if post.Domain() != "" {
+			external = append(external, i)
+		}
+	}
+
+	forEach(ctx, len(external), concurrency, e.Interval, func(i int) {
+		post := posts[external[i]]
+		content, err := fetchContent(ctx, client, timeout, maxBytes, post.URL)
+		content.Post = post
+		content.Err = err
+		results[external[i]] = content
+	})
+
+	return results
+}
+
+func fetchContent(ctx context.Context, client *http.Client, timeout time.Duration, maxBytes int64, url string) (PostContent, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return PostContent{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return PostContent{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return PostContent{}, errors.New("could not fetch article: " + resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" &&
+		mediaType != "application/xhtml+xml" {
+		return PostContent{}, ErrNotArticle
+	}
+
+	return ExtractContent(io.LimitReader(resp.Body, maxBytes))
+}
+
+var (
+	titleTagExpr       = xpath.MustCompile("//title")
+	headingExpr        = xpath.MustCompile("//h1")
+	bylineExpr         = xpath.MustCompile("//*[@rel='author' or contains(@class, 'byline') or contains(@class, 'author')]")
+	metaExpr           = xpath.MustCompile("//meta[@name or @property]")
+	clutterExpr        = xpath.MustCompile("//script|//style|//noscript|//nav|//header|//footer|//aside|//form")
+	paragraphExpr      = xpath.MustCompile("//p")
+	paragraphLinkExpr  = xpath.MustCompile("//a")
+	childParagraphExpr = xpath.MustCompile("/p")
+)
+
+// ExtractContent extracts the readable article from an HTML document.
+// Like readability, it picks the element whose paragraphs hold the most text, ignoring navigation and other clutter.
+func ExtractContent(r io.Reader) (PostContent, error) {
+	var content PostContent
+
+	doc, err := htmlquery.Parse(r)
+	if err != nil {
+		return content, err
+	}
+
+	content.Title = firstNonEmpty(
+		metaContent(doc, "og:title"), firstText(doc, titleTagExpr), firstText(doc, headingExpr))
+	content.Byline = firstNonEmpty(
+		metaContent(doc, "author"), metaContent(doc, "article:author"),
+		firstText(doc, bylineExpr))
+
+	for _, clutter := range htmlquery.QuerySelectorAll(doc, clutterExpr) {
+		clutter.Parent.RemoveChild(clutter)
+	}
+
+	// Score every element by the paragraph text it directly contains, less the text that is links
+	scores := map[*html.Node]int{}
+	var best *html.Node
+	for _, p := range htmlquery.QuerySelectorAll(doc, paragraphExpr) {
+		text := strings.TrimSpace(htmlquery.InnerText(p))
+		if len(text) < 25 {
+			continue
+		}
+		linkText := 0
+		for _, a := range htmlquery.QuerySelectorAll(p, paragraphLinkExpr) {
+			linkText += len(htmlquery.InnerText(a))
+		}
+
+		scores[p.Parent] += len(text) - linkText
+		if best == nil || scores[p.Parent] > scores[best] {
+			best = p.Parent
+		}
+	}
+
+	var paragraphs []string
+	if best != nil {
+		for _, p := range htmlquery.QuerySelectorAll(best, childParagraphExpr) {
+			if text := strings.Join(strings.Fields(htmlquery.InnerText(p)), " "); text != "" {
+				paragraphs = append(paragraphs, text)
+			}
+		}
+	} else if body := htmlquery.QuerySelector(doc, bodyExpr); body != nil {
+		paragraphs = append(paragraphs, strings.Join(strings.Fields(htmlquery.InnerText(body)), " "))
+	}
+
+	content.Text = strings.Join(paragraphs, "\n\n")
+	content.WordCount = len(strings.Fields(content.Text))
+	return content, nil
+}
+
+// metaContent returns the content of the meta tag with the given name or property.
+func metaContent(doc *html.Node, name string) string {
+	for _, meta := range htmlquery.QuerySelectorAll(doc, metaExpr) {
+		if htmlquery.SelectAttr(meta, "name") == name || htmlquery.SelectAttr(meta, "property") == name {
+			return strings.TrimSpace(htmlquery.SelectAttr(meta, "content"))
+		}
+	}
+
+	return ""
+}
+
+// firstText returns the normalized text of the first node matching the expression.
+func firstText(doc *html.Node, expr *xpath.Expr) string {
+	node := htmlquery.QuerySelector(doc, expr)
+	if node == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(htmlquery.InnerText(node)), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,58 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractContent(t *testing.T) {
+	f, err := os.Open("testdata/article.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	defer f.Close()
+
+	content, err := ExtractContent(f)
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+
+	if content.Title != "Why We Rewrote It" || content.Byline != "Jane Doe" {
+		t.Error("extracted title ", content.Title, " and byline ", content.Byline)
+	}
+	if !strings.HasPrefix(content.Text, "Three years ago we started with a small prototype that grew") ||
+		!strings.HasSuffix(content.Text, "what we learned.\n\nShort.") {
+		t.Errorf("extracted text %q", content.Text)
+	}
+	if content.WordCount != 36 {
+		t.Error("counted ", content.WordCount, " words instead of 36")
+	}
+}
+
+func TestContentExtractor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/paper.pdf" {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+			return
+		}
+		http.ServeFile(w, r, "testdata/article.html")
+	}))
+	defer server.Close()
+
+	posts := []Post{{ID: 1, URL: server.URL + "/article"}, {ID: 2, URL: server.URL + "/paper.pdf"}}
+	results := (&ContentExtractor{}).Extract(context.Background(), posts)
+
+	if results[0].Err != nil || results[0].Post.ID != 1 || results[0].Title != "Why We Rewrote It" {
+		t.Error("did not extract article: ", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrNotArticle) {
+		t.Error("returned ", results[1].Err, " instead of ErrNotArticle")
+	}
+}
@@ -0,0 +1,218 @@
+// Package corpus builds a research dataset of a whole day of HackerNews: the posts that made the day's front page
+// and every comment under them, written as newline-delimited JSON that refers between files by item ID.
+package corpus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// The files a corpus is written to, in its directory.
+const (
+	PostsFile    = "posts.ndjson"    // Every post as an hnscraper.Post, one per line, in front page order
+	CommentsFile = "comments.ndjson" // Every comment as an hnscraper.Comment without Replies, one per line, in thread order
+)
+
+// The defaults for Options.Pages and Options.Workers
+const (
+	defaultPages   = 1
+	defaultWorkers = 4
+)
+
+// Options configures Build.
+type Options struct {
+	Scraper *hnscraper.Scraper // Scrapes the front pages and items, nil for the default options
+	Pages   int                // How many pages of the day's front page to take, 0 for 1
+	Workers int                // How many items are scraped at once, 0 for 4. The scraper's rate limit still applies
+	Replay  []hnscraper.Page   // Snapshots to take the posts from instead of scraping, ie. from ReplayArchive or a store
+}
+
+// Stats counts what Build wrote.
+type Stats struct {
+	Posts    int // The posts written to PostsFile
+	Comments int // The comments written to CommentsFile
+	Failed   int // The posts whose comments couldn't be scraped, which are still written to PostsFile
+}
+
+// Build writes the corpus of day to dir, creating it if needed.
+//
+// The posts are those on the first Pages pages of HackerNews's past front page for the day, or, if Replay is set,
+// the latest version of every post on the snapshots retrieved during the day, in day's time zone. Either way every
+// post's item page is then scraped for its full comment tree, which is flattened so that each comment refers to
+// its post by StoryID and to what it replies to by ParentID.
+//
+// A post whose comments can't be scraped doesn't stop the others: the corpus is still written, and the failures are
+// returned in an *hnscraper.ItemsError along with the stats.
+func Build(ctx context.Context, day time.Time, dir string, opts Options) (Stats, error) {
+	var stats Stats
+	s := opts.Scraper
+	if s == nil {
+		s = hnscraper.NewScraper()
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	var posts []hnscraper.Post
+	var err error
+	if opts.Replay != nil {
+		posts = replayed(opts.Replay, day)
+	} else {
+		posts, err = scrapeDay(ctx, s, day, opts.Pages)
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		if post.ID != 0 {
+			ids = append(ids, post.ID)
+		}
+	}
+	items, itemsErr := s.ScrapeItems(ctx, ids, workers)
+	var failed *hnscraper.ItemsError
+	if itemsErr != nil && !errors.As(itemsErr, &failed) {
+		return stats, itemsErr
+	}
+	if failed != nil {
+		stats.Failed = len(failed.Errs)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return stats, err
+	}
+	err = writeLines(filepath.Join(dir, PostsFile), func(enc *json.Encoder) error {
+		for _, post := range posts {
+			if err := enc.Encode(post); err != nil {
+				return err
+			}
+			stats.Posts++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	err = writeLines(filepath.Join(dir, CommentsFile), func(enc *json.Encoder) error {
+		for _, item := range items {
+			if err := writeComments(enc, item.Comments, &stats); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	if failed != nil {
+		return stats, failed
+	}
+	return stats, nil
+}
+
+// scrapeDay scrapes the day's front page, stopping early if it runs out of posts.
+// Posts are kept once, at their first rank, in case the listing shifts between pages.
+func scrapeDay(ctx context.Context, s *hnscraper.Scraper, day time.Time, pages int) ([]hnscraper.Post, error) {
+	if pages <= 0 {
+		pages = defaultPages
+	}
+
+	var posts []hnscraper.Post
+	seen := make(map[string]bool)
+	for i := 1; i <= pages; i++ {
+		if err := ctx.Err(); err != nil {
+			return posts, err
+		}
+		page, err := s.ScrapeFrontDay(day, i)
+		if err != nil {
+			return posts, &hnscraper.PagesError{Page: i, Err: err}
+		}
+		if len(page.Posts) == 0 {
+			break
+		}
+		for _, post := range page.Posts {
+			if !seen[post.Key()] {
+				seen[post.Key()] = true
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	return posts, nil
+}
+
+// replayed returns the latest version of every post on the snapshots retrieved on the day,
+// in the order the snapshots first show them.
+func replayed(pages []hnscraper.Page, day time.Time) []hnscraper.Post {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	to := from.AddDate(0, 0, 1)
+
+	var posts []hnscraper.Post
+	index := make(map[string]int)
+	retrieved := make(map[string]time.Time)
+	for _, page := range pages {
+		if page.Retrieved.Before(from) || !page.Retrieved.Before(to) {
+			continue
+		}
+		for _, post := range page.Posts {
+			key := post.Key()
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(posts)
+				posts = append(posts, post)
+				retrieved[key] = page.Retrieved
+			} else if !page.Retrieved.Before(retrieved[key]) {
+				posts[i] = post
+				retrieved[key] = page.Retrieved
+			}
+		}
+	}
+
+	return posts
+}
+
+// writeComments writes the comment tree depth first, each comment without its replies.
+func writeComments(enc *json.Encoder, comments []hnscraper.Comment, stats *Stats) error {
+	for _, comment := range comments {
+		replies := comment.Replies
+		comment.Replies = nil
+		if err := enc.Encode(comment); err != nil {
+			return err
+		}
+		stats.Comments++
+		if err := writeComments(enc, replies, stats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeLines creates the file and hands write an encoder writing JSON lines to it.
+func writeLines(path string, write func(*json.Encoder) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(json.NewEncoder(w)); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
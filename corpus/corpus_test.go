@@ -0,0 +1,156 @@
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+)
+
+var day = time.Date(2021, 10, 16, 0, 0, 0, 0, time.UTC)
+
+func newTestSite() *hnscrapertest.Server {
+	posted := day.Add(12 * time.Hour)
+	return hnscrapertest.NewServer(
+		hnscrapertest.Story{ID: 1, Title: "Rust in production", By: "alice", Score: 120, Posted: posted,
+			Comments: []hnscrapertest.Comment{
+				{ID: 10, By: "bob", Text: "Great read", Posted: posted},
+				{ID: 11, By: "carol", Text: "Agreed", Posted: posted, Depth: 1},
+				{ID: 12, By: "dave", Text: "Not convinced", Posted: posted},
+			}},
+		hnscrapertest.Story{ID: 2, Title: "Go generics", By: "erin", Score: 80, Posted: posted,
+			Comments: []hnscrapertest.Comment{{ID: 20, By: "frank", Text: "Finally", Posted: posted}}},
+		hnscrapertest.Story{ID: 3, Title: "Yesterday's news", By: "grace", Score: 10, Posted: day.Add(-time.Hour)},
+	)
+}
+
+// readLines decodes every line of a corpus file.
+func readLines(t *testing.T, path string, decode func(*json.Decoder) error) int {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if err := decode(json.NewDecoder(bytes.NewReader(scanner.Bytes()))); err != nil {
+			t.Fatal("error: ", err)
+		}
+		lines++
+	}
+
+	return lines
+}
+
+func TestBuild(t *testing.T) {
+	site := newTestSite()
+	defer site.Close()
+	dir := filepath.Join(t.TempDir(), "2021-10-16")
+
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+	stats, err := Build(context.Background(), day, dir, Options{Scraper: s, Pages: 3, Workers: 2})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if stats != (Stats{Posts: 2, Comments: 4}) {
+		t.Error("wrote ", stats)
+	}
+
+	var posts []hnscraper.Post
+	readLines(t, filepath.Join(dir, PostsFile), func(dec *json.Decoder) error {
+		var post hnscraper.Post
+		err := dec.Decode(&post)
+		posts = append(posts, post)
+		return err
+	})
+	if len(posts) != 2 || posts[0].ID != 1 || posts[1].ID != 2 || posts[0].Rank != 1 {
+		t.Fatal("wrote posts ", posts)
+	}
+
+	ids := make(map[int]bool)
+	for _, post := range posts {
+		ids[post.ID] = true
+	}
+	var comments []hnscraper.Comment
+	readLines(t, filepath.Join(dir, CommentsFile), func(dec *json.Decoder) error {
+		var comment hnscraper.Comment
+		err := dec.Decode(&comment)
+		comments = append(comments, comment)
+		ids[comment.ID] = true
+		return err
+	})
+	if len(comments) != 4 || comments[1].ID != 11 || comments[1].ParentID != 10 || comments[3].StoryID != 2 {
+		t.Fatal("wrote comments ", comments)
+	}
+	for _, comment := range comments {
+		if !ids[comment.StoryID] || !ids[comment.ParentID] || comment.Replies != nil {
+			t.Error("comment ", comment.ID, " doesn't refer to the dataset by ID")
+		}
+	}
+}
+
+func TestBuildReplay(t *testing.T) {
+	site := newTestSite()
+	defer site.Close()
+	dir := t.TempDir()
+
+	replay := []hnscraper.Page{
+		{Num: 1, Retrieved: day.Add(time.Hour), Posts: []hnscraper.Post{{ID: 2, Rank: 1, Score: 10}, {ID: 1, Rank: 2, Score: 5}}},
+		{Num: 1, Retrieved: day.Add(2 * time.Hour), Posts: []hnscraper.Post{{ID: 1, Rank: 1, Score: 50}}},
+		{Num: 1, Retrieved: day.Add(-time.Hour), Posts: []hnscraper.Post{{ID: 3, Rank: 1}}},
+	}
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+	before := site.Requests()
+	stats, err := Build(context.Background(), day.Add(18*time.Hour), dir, Options{Scraper: s, Replay: replay})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if stats != (Stats{Posts: 2, Comments: 4}) {
+		t.Error("wrote ", stats)
+	}
+	if n := site.Requests() - before; n != 2 {
+		t.Error("made ", n, " requests instead of one per item")
+	}
+
+	var posts []hnscraper.Post
+	readLines(t, filepath.Join(dir, PostsFile), func(dec *json.Decoder) error {
+		var post hnscraper.Post
+		err := dec.Decode(&post)
+		posts = append(posts, post)
+		return err
+	})
+	if len(posts) != 2 || posts[0].ID != 2 || posts[1].ID != 1 || posts[1].Score != 50 {
+		t.Error("did not keep the latest version of the day's posts: ", posts)
+	}
+}
+
+func TestBuildFailedItem(t *testing.T) {
+	site := newTestSite()
+	defer site.Close()
+	dir := t.TempDir()
+
+	replay := []hnscraper.Page{{Num: 1, Retrieved: day, Posts: []hnscraper.Post{{ID: 1}, {ID: 2}}}}
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+	site.Throttle(1)
+	stats, err := Build(context.Background(), day, dir, Options{Scraper: s, Replay: replay, Workers: 1})
+
+	var itemsErr *hnscraper.ItemsError
+	if !errors.As(err, &itemsErr) || itemsErr.Errs[1] == nil {
+		t.Fatal("returned ", err, " instead of the failed item")
+	}
+	if stats.Posts != 2 || stats.Failed != 1 || stats.Comments != 1 {
+		t.Error("wrote ", stats)
+	}
+}
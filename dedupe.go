@@ -0,0 +1,100 @@
+package hnscraper
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A Deduper drops posts it has already seen, such as a story that moved to the next page between scrapes.
+// Posts are matched by Post.Key, their item ID where they have one, and optionally by canonical URL to also catch
+// resubmissions.
+// A Deduper is safe for concurrent use.
+type Deduper struct {
+	matchURLs bool
+	mu        sync.Mutex
+	seenKeys  map[string]bool
+	seenURLs  map[string]bool
+}
+
+// NewDeduper creates a Deduper.
+// If matchURLs is true, posts linking to the same canonical URL as an earlier post are also dropped.
+func NewDeduper(matchURLs bool) *Deduper {
+	return &Deduper{
+		matchURLs: matchURLs,
+		seenKeys:  make(map[string]bool),
+		seenURLs:  make(map[string]bool),
+	}
+}
+
+// Seen reports whether an equivalent post was already seen, and records the post either way.
+func (d *Deduper) Seen(p Post) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := p.Key()
+	seen := d.seenKeys[key]
+	d.seenKeys[key] = true
+
+	// Posts without an external link only match by key
+	if d.matchURLs && p.Domain() != "" {
+		canonical := CanonicalURL(p.URL)
+		seen = seen || d.seenURLs[canonical]
+		d.seenURLs[canonical] = true
+	}
+
+	return seen
+}
+
+// Posts returns the posts that haven't been seen before, in their original order.
+func (d *Deduper) Posts(posts []Post) []Post {
+	var unseen []Post
+	for _, p := range posts {
+		if !d.Seen(p) {
+			unseen = append(unseen, p)
+		}
+	}
+
+	return unseen
+}
+
+// Pages returns copies of the pages holding only the posts that haven't been seen before.
+func (d *Deduper) Pages(pages []Page) []Page {
+	deduped := make([]Page, len(pages))
+	for i, page := range pages {
+		page.Posts = d.Posts(page.Posts)
+		deduped[i] = page
+	}
+
+	return deduped
+}
+
+// CanonicalURL normalizes a URL so that trivially different links to the same content compare equal.
+// It lowercases the scheme and host, drops "www.", default ports, fragments, trailing slashes, and utm_* tracking parameters.
+// URLs that can't be parsed are returned unchanged.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
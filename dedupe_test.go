@@ -0,0 +1,55 @@
+package hnscraper
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	cases := map[string]string{
+		"https://www.Example.com/post/":                       "https://example.com/post",
+		"https://example.com:443/post?utm_source=hn&id=3#top": "https://example.com/post?id=3",
+		"HTTP://example.com:8080/":                            "http://example.com:8080",
+		"item?id=123":                                         "item?id=123",
+	}
+
+	for raw, want := range cases {
+		if canonical := CanonicalURL(raw); canonical != want {
+			t.Errorf("canonicalized %q to %q instead of %q", raw, canonical, want)
+		}
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	pages := []Page{
+		{Num: 1, Posts: []Post{
+			{ID: 1, URL: "https://example.com/a"},
+			{ID: 2, URL: "item?id=2"},
+		}},
+		{Num: 2, Posts: []Post{
+			{ID: 1, URL: "https://example.com/a"},                   // Moved down a page
+			{ID: 3, URL: "https://www.example.com/a/?utm_medium=x"}, // Resubmission
+			{ID: 4, URL: "item?id=4"},
+		}},
+	}
+
+	if deduped := NewDeduper(false).Pages(pages); len(deduped[1].Posts) != 2 {
+		t.Error("kept ", len(deduped[1].Posts), " posts on the second page instead of 2")
+	}
+	if deduped := NewDeduper(true).Pages(pages); len(deduped[1].Posts) != 1 {
+		t.Error("kept ", len(deduped[1].Posts), " posts on the second page instead of 1 when matching URLs")
+	}
+	if len(pages[1].Posts) != 3 {
+		t.Error("modified the original pages")
+	}
+}
+
+func TestDeduperWithoutIDs(t *testing.T) {
+	d := NewDeduper(false)
+	posts := []Post{
+		{Title: "First", URL: "https://example.com/a"},
+		{Title: "Second", URL: "https://example.com/b"},
+		{Title: "First", URL: "https://example.com/a"},
+	}
+
+	if unseen := d.Posts(posts); len(unseen) != 2 || unseen[1].Title != "Second" {
+		t.Error("kept ", unseen, " instead of the two different posts without IDs")
+	}
+}
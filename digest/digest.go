@@ -0,0 +1,165 @@
+// Package digest renders summaries of the top HackerNews posts over a period and emails them.
+package digest
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+// A Digest is a summary of the top posts submitted within a period.
+type Digest struct {
+	Title string           // The subject of the email, ie. "HN Daily Digest"
+	From  time.Time        // The start of the period, inclusive
+	To    time.Time        // The end of the period, exclusive
+	Posts []hnscraper.Post // The posts in the digest, from highest to lowest score
+}
+
+// New builds a digest from the posts submitted within [from, to) that the filter keeps,
+// holding at most limit posts from highest to lowest score. A nil filter keeps every post; a limit of 0 has no limit.
+func New(title string, posts []hnscraper.Post, from, to time.Time, f filter.Filter, limit int) Digest {
+	inPeriod := func(p hnscraper.Post) bool {
+		return !p.TimePosted.Before(from) && p.TimePosted.Before(to)
+	}
+	if f != nil {
+		inPeriod = filter.And(inPeriod, f)
+	}
+
+	selected := hnscraper.Posts(filter.Posts(posts, inPeriod)).SortByScore()
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+
+	return Digest{Title: title, From: from, To: to, Posts: selected}
+}
+
+// Daily builds a digest of the 24 hours before the end time. See New.
+func Daily(posts []hnscraper.Post, end time.Time, f filter.Filter, limit int) Digest {
+	return New("HN Daily Digest", posts, end.Add(-24*time.Hour), end, f, limit)
+}
+
+// Weekly builds a digest of the 7 days before the end time. See New.
+func Weekly(posts []hnscraper.Post, end time.Time, f filter.Filter, limit int) Digest {
+	return New("HN Weekly Digest", posts, end.Add(-7*24*time.Hour), end, f, limit)
+}
+
+var funcs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format("Jan 2, 2006")
+	},
+	"domain": func(p hnscraper.Post) string {
+		return p.Domain()
+	},
+	"discussion": func(p hnscraper.Post) string {
+		// Posts stored without a discussion link, ie. imported ones, are linked to HackerNews itself
+		if p.CommentsURL != "" {
+			return p.CommentsURL
+		}
+		return "https://news.ycombinator.com/item?id=" + strconv.Itoa(p.ID)
+	},
+}
+
+var textTemplate = template.Must(template.New("text").Funcs(funcs).Parse(
+	`{{.Title}}: {{date .From}} - {{date .To}}
+{{range $i, $p := .Posts}}
+{{$p.Score}} points | {{$p.NumComments}} comments | {{$p.Title}}{{with domain $p}} ({{.}}){{end}}
+  {{$p.URL}}
+  {{discussion $p}}
+{{else}}
+No posts matched this period.
+{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(
+	`<!DOCTYPE html>
+<html><body style="font-family: Verdana, Geneva, sans-serif; font-size: 10pt;">
+<h2 style="color: #ff6600;">{{.Title}}</h2>
+<p style="color: #828282;">{{date .From}} - {{date .To}}</p>
+{{if .Posts}}<table cellpadding="4">
+{{range .Posts}}<tr>
+<td align="right" valign="top" style="color: #828282;">{{.Score}}</td>
+<td><a href="{{.URL}}" style="color: #000000;">{{.Title}}</a>{{with domain .}} <span style="color: #828282;">({{.}})</span>{{end}}<br>
+<a href="{{discussion .}}" style="color: #828282;">{{.NumComments}} comments</a></td>
+</tr>
+{{end}}</table>{{else}}<p>No posts matched this period.</p>{{end}}
+</body></html>
+`))
+
+// Text renders the digest as plain text.
+func (d Digest) Text() (string, error) {
+	var buf bytes.Buffer
+	err := textTemplate.Execute(&buf, d)
+
+	return buf.String(), err
+}
+
+// HTML renders the digest as an HTML document.
+func (d Digest) HTML() (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, d)
+
+	return buf.String(), err
+}
+
+// Message renders the digest as an email with both plain text and HTML parts.
+func (d Digest) Message(from string, to []string) ([]byte, error) {
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	html, err := d.HTML()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", d.Title) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: multipart/alternative; boundary=" + parts.Boundary() + "\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// Send emails the digest through the SMTP server at addr, ie. "smtp.example.com:587".
+func Send(addr string, auth smtp.Auth, from string, to []string, d Digest) error {
+	msg, err := d.Message(from, to)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(addr, auth, from, to, msg)
+}
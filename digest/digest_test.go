@@ -0,0 +1,96 @@
+package digest
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+var end = time.Date(2021, 10, 16, 0, 0, 0, 0, time.UTC)
+
+var posts = []hnscraper.Post{
+	{ID: 1, Title: "Old news", Score: 900, URL: "https://example.com/old", TimePosted: end.Add(-48 * time.Hour)},
+	{ID: 2, Title: "Rust & <Go>", Score: 50, NumComments: 7, URL: "https://github.com/a/b", TimePosted: end.Add(-2 * time.Hour),
+		CommentsURL: "https://hn.example/item?id=2"},
+	{ID: 3, Title: "Top story", Score: 300, NumComments: 120, URL: "https://www.example.com/top", TimePosted: end.Add(-10 * time.Hour)},
+	{ID: 4, Title: "Low score", Score: 2, URL: "https://example.com/low", TimePosted: end.Add(-time.Hour)},
+}
+
+func TestDaily(t *testing.T) {
+	d := Daily(posts, end, filter.ByMinScore(10), 0)
+
+	if len(d.Posts) != 2 || d.Posts[0].ID != 3 || d.Posts[1].ID != 2 {
+		t.Error("selected incorrect posts: ", d.Posts)
+	}
+	if limited := Daily(posts, end, nil, 1); len(limited.Posts) != 1 || limited.Posts[0].ID != 3 {
+		t.Error("did not limit the digest to the top post")
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := Daily(posts, end, filter.ByMinScore(10), 0)
+
+	text, err := d.Text()
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if !strings.Contains(text, "300 points | 120 comments | Top story (example.com)") ||
+		!strings.Contains(text, "https://news.ycombinator.com/item?id=3") || !strings.Contains(text, "https://hn.example/item?id=2") {
+		t.Error("rendered incorrect text: ", text)
+	}
+
+	html, err := d.HTML()
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if !strings.Contains(html, "Rust &amp; &lt;Go&gt;") || strings.Contains(html, "<Go>") {
+		t.Error("did not escape titles in html: ", html)
+	}
+
+	if empty, _ := New("Empty", nil, end, end, nil, 0).Text(); !strings.Contains(empty, "No posts matched") {
+		t.Error("rendered empty digest incorrectly: ", empty)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	d := Daily(posts, end, nil, 0)
+	raw, err := d.Message("digest@example.com", []string{"me@example.com"})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if msg.Header.Get("Subject") != "HN Daily Digest" {
+		t.Error("set subject to ", msg.Header.Get("Subject"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatal("set content type to ", msg.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal("error: ", err)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Error("wrote parts ", types, " instead of text and html")
+	}
+}
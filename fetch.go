@@ -0,0 +1,333 @@
+package hnscraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// ErrThrottled is returned when HackerNews refuses a request because too many were made too quickly.
+var ErrThrottled = errors.New("hackernews is throttling requests")
+
+// ErrBudgetExceeded is returned once a Scraper has made as many requests as WithMaxRequests allows.
+// Functions that scrape several pages return the pages collected before the budget ran out alongside it.
+var ErrBudgetExceeded = errors.New("request budget exceeded")
+
+// ErrBodyTooLarge is returned when a response is larger than WithMaxBodySize allows.
+var ErrBodyTooLarge = errors.New("response body exceeds size limit")
+
+// ErrNotHTML is returned when a page is served with a content type other than HTML.
+var ErrNotHTML = errors.New("response is not html")
+
+// The default limit for WithMaxBodySize. The largest HN threads are a few megabytes.
+const defaultMaxBodySize = 32 << 20
+
+// fetch requests a page from HackerNews and parses it, honoring the circuit breaker and request budget if configured.
+func (s *Scraper) fetch(ctx context.Context, url string) (*html.Node, error) {
+	var doc *html.Node
+	err := s.fetchBody(ctx, url, true, func(r io.Reader) (err error) {
+		if doc, err = htmlquery.Parse(r); err == nil {
+			s.checkPooledSession(r, doc)
+		}
+		return err
+	})
+
+	return doc, err
+}
+
+// fetchBody requests a page from HackerNews like fetch, handing the response body to read instead of parsing it.
+// Failed attempts are retried if WithRetries allows, unless read has already been handed a body and isn't restartable.
+func (s *Scraper) fetchBody(ctx context.Context, url string, restartable bool, read func(io.Reader) error) error {
+	return s.guard(ctx, url, func(ctx context.Context) (attempts int, err error) {
+		backoff := s.retryBackoff
+		for attempt := 0; ; attempt++ {
+			attempts++
+			var started, timedOut bool
+			timedOut, err = s.attempt(ctx, url, func(r io.Reader) error {
+				started = true
+				return read(r)
+			})
+			if err == nil || attempt >= s.retries || ctx.Err() != nil || (started && !restartable) || !retryable(err, timedOut) {
+				return attempts, err
+			}
+
+			s.logf("retrying %s after attempt %d failed: %v", url, attempt+1, err)
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return attempts, ctx.Err()
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+	})
+}
+
+// guard makes the requests to url with request inside a fetch span, behind the circuit breaker and robots.txt check
+// that every request to HackerNews goes through. request returns how many attempts it made.
+func (s *Scraper) guard(ctx context.Context, url string, request func(context.Context) (int, error)) (err error) {
+	ctx, span := s.startSpan(ctx, SpanFetch)
+	span.SetString("url", url)
+	attempts := 0
+	defer func() {
+		span.SetInt("attempts", attempts)
+		span.End(err)
+	}()
+
+	if s.breaker != nil {
+		if err := s.breaker.allow(); err != nil {
+			return err
+		}
+		defer func() {
+			// Cancellation is the caller's doing, and refusals of our own never reached HackerNews,
+			// so neither is a sign that it is struggling
+			if ctx.Err() != nil || refusedLocally(err) {
+				s.breaker.release()
+			} else {
+				s.breaker.record(err)
+			}
+		}()
+	}
+	if s.respectRobots {
+		if err := s.checkRobots(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	attempts, err = request(ctx)
+	return err
+}
+
+// refusedLocally reports whether a request was refused by the Scraper itself rather than failing upstream.
+func refusedLocally(err error) bool {
+	return errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrDisallowed) || errors.Is(err, ErrNoSessions) ||
+		errors.Is(err, ErrPostingTooFast)
+}
+
+// attempt makes a single request, counted against the budget and spaced out by the rate limit,
+// reporting whether it failed because the per-request timeout ran out.
+func (s *Scraper) attempt(ctx context.Context, url string, read func(io.Reader) error) (bool, error) {
+	if !s.spendRequest() {
+		return false, ErrBudgetExceeded
+	}
+	interval := s.interval
+	if delay := s.crawlDelay(); delay > interval {
+		interval = delay
+	}
+	if err := s.wait(ctx, interval); err != nil {
+		return false, err
+	}
+
+	if s.requestTimeout <= 0 {
+		return false, s.load(ctx, url, read)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	err := s.load(attemptCtx, url, read)
+	return err != nil && attemptCtx.Err() != nil && ctx.Err() == nil, err
+}
+
+// retryable reports whether a failed attempt is worth repeating: it timed out, the connection failed,
+// or HackerNews had a server error. Throttling is not retried, as hammering a throttled server only prolongs it.
+func retryable(err error, timedOut bool) bool {
+	if timedOut {
+		return true
+	}
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// statusError reports a response with an unexpected status.
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d from %s", e.code, e.url)
+}
+
+// operation applies the operation timeout set by WithOperationTimeout to a public call's context.
+func (s *Scraper) operation(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.operationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.operationTimeout)
+}
+
+func (s *Scraper) load(ctx context.Context, url string, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, read)
+}
+
+// do sends the request with the session cookie or the next pooled session, if any, checks the response is a reasonably sized HTML page,
+// archives it if it was a GET made without a session, and hands its body to read as a *metaReader.
+func (s *Scraper) do(req *http.Request, read func(io.Reader) error) error {
+	url := req.URL.String()
+	var pooled string
+	if s.session != "" {
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: s.session})
+	} else if s.sessionPool != nil {
+		var err error
+		if pooled, err = s.sessionPool.take(req.Context()); err != nil {
+			return err
+		}
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: pooled})
+	}
+
+	sent := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	meta := PageMeta{Status: resp.StatusCode, Latency: time.Since(sent), FinalURL: url}
+	// Custom transports don't always say which request a response answers
+	if resp.Request != nil {
+		meta.FinalURL = resp.Request.URL.String()
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return ErrThrottled
+	} else if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode, url: url}
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return fmt.Errorf("%w: %s served %q", ErrNotHTML, url, contentType)
+		}
+	}
+	maxBody := s.maxBodySize()
+	if resp.ContentLength > maxBody {
+		return fmt.Errorf("%w: %s is %d bytes", ErrBodyTooLarge, url, resp.ContentLength)
+	}
+	// The length isn't always declared, or truthful
+	bodyReader := &limitedReader{r: resp.Body, n: maxBody}
+
+	// Pages fetched as a logged-in account carry its vote and action auth tokens, which mustn't end up in an archive
+	loggedIn := s.session != "" || pooled != ""
+	if (s.archiveDir == "" && s.archiveFunc == nil) || req.Method != http.MethodGet || loggedIn {
+		return read(&metaReader{Reader: bodyReader, meta: meta, pooled: pooled})
+	}
+
+	body, err := io.ReadAll(bodyReader)
+	if err != nil {
+		return err
+	}
+	if err := s.archive(url, time.Now(), body); err != nil {
+		return err
+	}
+
+	return read(&metaReader{Reader: bytes.NewReader(body), meta: meta, pooled: pooled})
+}
+
+// metaReader is a response body that carries how the response was served, for readers that record it.
+type metaReader struct {
+	io.Reader
+	meta   PageMeta
+	pooled string // The pooled session the request was made with, if any
+}
+
+// maxBodySize is the most bytes a response may have.
+func (s *Scraper) maxBodySize() int64 {
+	if s.maxBody > 0 {
+		return s.maxBody
+	}
+
+	return defaultMaxBodySize
+}
+
+// limitedReader reads at most n bytes, failing with ErrBodyTooLarge if there are more
+// rather than silently truncating like io.LimitReader.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Make sure there is more before failing, so a body of exactly the limit is accepted
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	return n, err
+}
+
+// spendRequest counts a request against the budget, reporting false if the budget is already spent.
+func (s *Scraper) spendRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxRequests > 0 && s.requests >= s.maxRequests {
+		return false
+	}
+	s.requests++
+
+	return true
+}
+
+// wait blocks until at least interval, plus any jitter, has passed since the previous request's turn.
+// Each caller reserves the next free turn, so concurrent requests are spaced out too.
+func (s *Scraper) wait(ctx context.Context, interval time.Duration) error {
+	if s.jitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(s.jitter)))
+	}
+
+	s.mu.Lock()
+	turn := s.lastTurn.Add(interval)
+	if now := time.Now(); turn.Before(now) {
+		turn = now
+	}
+	s.lastTurn = turn
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(turn))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Requests returns how many requests the Scraper has made.
+func (s *Scraper) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
@@ -0,0 +1,136 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxRequests(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithMaxRequests(2))
+	pages, err := s.ScrapeMultPages(1, 5)
+
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Error("returned ", err, " instead of ErrBudgetExceeded")
+	}
+	if len(pages) != 2 {
+		t.Error("returned ", len(pages), " partial pages instead of 2")
+	}
+	if s.Requests() != 2 {
+		t.Error("made ", s.Requests(), " requests with a budget of 2")
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	// Declared too large up front
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithMaxBodySize(1024))
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrBodyTooLarge) {
+		t.Error("returned ", err, " instead of ErrBodyTooLarge for a declared length")
+	}
+
+	// Streamed without a length
+	s = newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		for i := 0; i < 4; i++ {
+			w.Write(fixture)
+			w.(http.Flusher).Flush()
+		}
+	}, WithMaxBodySize(int64(len(fixture))))
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrBodyTooLarge) {
+		t.Error("returned ", err, " instead of ErrBodyTooLarge for a chunked body")
+	}
+
+	// Exactly at the limit
+	s = newTestScraper(t, serveFile("testdata/news.html"), WithMaxBodySize(int64(len(fixture))))
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Error("error: ", err)
+	}
+}
+
+func TestNotHTML(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0, 1, 2})
+	})
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrNotHTML) {
+		t.Error("returned ", err, " instead of ErrNotHTML")
+	}
+}
+
+// slowThenServe delays the first slow requests by delay, then serves the news fixture.
+func slowThenServe(slow int32, delay time.Duration, requests *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= slow {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}
+}
+
+func TestRequestTimeoutRetries(t *testing.T) {
+	var requests int32
+	s := newTestScraper(t, slowThenServe(1, time.Second, &requests),
+		WithRequestTimeout(50*time.Millisecond), WithRetries(2, time.Millisecond))
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Error("error: ", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Error("made ", n, " requests instead of retrying once")
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	s = newTestScraper(t, slowThenServe(1, time.Second, &requests), WithRequestTimeout(50*time.Millisecond))
+	if _, err := s.ScrapePage(1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("returned ", err, " instead of timing out")
+	}
+}
+
+func TestRetryOnlyTransientErrors(t *testing.T) {
+	var requests int32
+	status := int32(http.StatusInternalServerError)
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}, WithRetries(2, time.Millisecond))
+
+	if _, err := s.ScrapePage(1); err == nil {
+		t.Error("accepted a server error")
+	}
+	if n := atomic.SwapInt32(&requests, 0); n != 3 {
+		t.Error("made ", n, " requests for a server error instead of 3")
+	}
+
+	atomic.StoreInt32(&status, http.StatusNotFound)
+	if _, err := s.ScrapePage(1); err == nil {
+		t.Error("accepted a missing page")
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Error("retried a missing page ", n-1, " times")
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	var requests int32
+	s := newTestScraper(t, slowThenServe(100, 40*time.Millisecond, &requests), WithOperationTimeout(100*time.Millisecond))
+
+	pages, err := s.ScrapeMultPages(1, 10)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("returned ", err, " instead of running out of time")
+	}
+	if len(pages) == 0 || len(pages) >= 10 {
+		t.Error("scraped ", len(pages), " pages before the deadline")
+	}
+}
@@ -0,0 +1,167 @@
+package hnscraper
+
+import (
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// A Field is a part of a post that is extracted from a listing row, see WithFieldExtractor.
+type Field int
+
+// The fields of a post that can be extracted with a custom FieldFunc, in the order they are extracted.
+const (
+	FieldID          Field = iota // Sets Post.ID
+	FieldRank                     // Sets Post.Rank
+	FieldTitle                    // Sets Post.RawTitle, from which Post.Title is normalized
+	FieldURL                      // Sets Post.URL
+	FieldAuthor                   // Sets Post.By
+	FieldScore                    // Sets Post.Score
+	FieldNumComments              // Sets Post.NumComments
+	FieldTimePosted               // Sets Post.TimePosted and Post.TimeApprox
+	numFields
+)
+
+var fieldNames = [numFields]string{"id", "rank", "title", "url", "author", "score", "comments", "time"}
+
+// String returns the field's name, ie. "score".
+func (f Field) String() string {
+	if f < 0 || f >= numFields {
+		return "unknown"
+	}
+
+	return fieldNames[f]
+}
+
+// A Row is the markup of a single post on a listing page.
+type Row struct {
+	Title     *html.Node     // The row holding the rank, title, and link
+	Subtext   *html.Node     // The cell below it holding the score, author, age, and comment count, nil if unused, see WithFields
+	Retrieved time.Time      // When the page was retrieved
+	Location  *time.Location // The time zone times are reported in
+}
+
+// subtextFields are the fields extracted from a row's subtext.
+var subtextFields = []Field{FieldAuthor, FieldScore, FieldNumComments, FieldTimePosted}
+
+// WithFields extracts only the fields from listing rows, leaving the rest of each post's fields zero, ie. titles and
+// URLs for a monitor that only watches for new posts. When none of the fields come from the subtext below the title
+// (the author, score, comment count, and time), it isn't looked at at all, so changes to its markup can't break
+// scraping. The ID is always extracted, as it identifies the post. Pinned, Promoted, and SecondChance are only set
+// when the fields they are worked out from are extracted.
+func WithFields(fields ...Field) Option {
+	return func(s *Scraper) {
+		s.fields = map[Field]bool{FieldID: true}
+		for _, field := range fields {
+			s.fields[field] = true
+		}
+	}
+}
+
+// wants reports whether the field is extracted.
+func (s *Scraper) wants(fields ...Field) bool {
+	if s.fields == nil {
+		return true
+	}
+	for _, field := range fields {
+		if !s.fields[field] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// needsSubtext reports whether any extracted field comes from the subtext.
+func (s *Scraper) needsSubtext() bool {
+	for _, field := range subtextFields {
+		if s.wants(field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A FieldFunc extracts a field from a row into the post.
+type FieldFunc func(row Row, post *Post) error
+
+// DefaultExtractor returns the built-in FieldFunc for the field, for custom extractors that only adjust its result.
+func DefaultExtractor(field Field) FieldFunc {
+	switch field {
+	case FieldID:
+		return func(row Row, post *Post) (err error) {
+			post.ID, err = getID(row.Title)
+			return err
+		}
+	case FieldRank:
+		return func(row Row, post *Post) (err error) {
+			post.Rank, err = getRank(row.Title)
+			return err
+		}
+	case FieldTitle:
+		return func(row Row, post *Post) (err error) {
+			post.RawTitle, err = getTitle(row.Title)
+			return err
+		}
+	case FieldURL:
+		return func(row Row, post *Post) (err error) {
+			post.URL, err = getURL(row.Title)
+			return err
+		}
+	case FieldAuthor:
+		return func(row Row, post *Post) (err error) {
+			post.By, err = getAuthor(row.Subtext)
+			return err
+		}
+	case FieldScore:
+		return func(row Row, post *Post) (err error) {
+			post.Score, err = getPoints(row.Subtext)
+			return err
+		}
+	case FieldNumComments:
+		return func(row Row, post *Post) (err error) {
+			post.NumComments, err = getNumComments(row.Subtext)
+			return err
+		}
+	case FieldTimePosted:
+		return func(row Row, post *Post) error {
+			posted, err := getTimePosted(row.Subtext, row.Location)
+			if err != nil {
+				// Estimating the time is better than losing the whole page over one odd row
+				if posted, err = getRelativeTime(row.Subtext, row.Retrieved.In(row.Location)); err != nil {
+					return err
+				}
+				post.TimeApprox = true
+			}
+			post.TimePosted = posted
+			return nil
+		}
+	}
+
+	return func(row Row, post *Post) error { return nil }
+}
+
+// WithFieldExtractor replaces how a field is extracted from listing rows, ie. to work around a markup change
+// before the package is updated. The other fields are still extracted by the built-in extractors,
+// and fields extracted earlier are already set on the post fn is given.
+//
+//	hnscraper.WithFieldExtractor(hnscraper.FieldURL, func(row hnscraper.Row, post *hnscraper.Post) error {
+//		link := htmlquery.FindOne(row.Title, "//span[@class='titleline']/a")
+//		if link == nil {
+//			return errors.New("no title link")
+//		}
+//		post.URL = htmlquery.SelectAttr(link, "href")
+//		return nil
+//	})
+func WithFieldExtractor(field Field, fn FieldFunc) Option {
+	return func(s *Scraper) {
+		if field < 0 || field >= numFields {
+			return
+		}
+		if s.extractors == nil {
+			s.extractors = make(map[Field]FieldFunc)
+		}
+		s.extractors[field] = fn
+	}
+}
@@ -0,0 +1,80 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithFieldExtractor(t *testing.T) {
+	urlDefault := DefaultExtractor(FieldURL)
+	s := newTestScraper(t, serveFile("testdata/news.html"),
+		// Replace a field outright
+		WithFieldExtractor(FieldAuthor, func(row Row, post *Post) error {
+			post.By = "user" + strings.Repeat("!", post.Rank)
+			return nil
+		}),
+		// Adjust the built-in result
+		WithFieldExtractor(FieldURL, func(row Row, post *Post) error {
+			if err := urlDefault(row, post); err != nil {
+				return err
+			}
+			post.URL = strings.TrimPrefix(post.URL, "https://")
+			return nil
+		}))
+
+	page, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	first := page.Posts[0]
+	if first.By != "user!" || first.URL != "github.com/example/project" || first.Score != 312 {
+		t.Error("extracted ", first)
+	}
+
+	failure := errors.New("markup changed")
+	s = newTestScraper(t, serveFile("testdata/news.html"), WithFieldExtractor(FieldScore, func(row Row, post *Post) error {
+		return failure
+	}))
+	if _, err := s.ScrapePage(1); !errors.Is(err, failure) {
+		t.Error("returned ", err, " instead of the extractor's error")
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	// The subtext markup changes beyond recognition
+	broken := strings.ReplaceAll(string(fixture), `class="subtext"`, `class="meta"`)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(broken))
+	}
+
+	if _, err := newTestScraper(t, handler).ScrapePage(1); err == nil {
+		t.Fatal("parsed a page without subtexts with every field")
+	}
+
+	page, err := newTestScraper(t, handler, WithFields(FieldTitle, FieldURL)).ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(page.Posts) == 0 {
+		t.Fatal("parsed no posts")
+	}
+	for _, post := range page.Posts {
+		if post.ID == 0 || post.Title == "" || post.URL == "" {
+			t.Errorf("left out a requested field: %+v", post)
+		}
+		if post.Rank != 0 || post.Score != 0 || post.By != "" || !post.TimePosted.IsZero() || post.Pinned || post.Promoted {
+			t.Errorf("extracted fields that weren't requested: %+v", post)
+		}
+		if post.Job || post.Type() == TypeJob {
+			t.Errorf("took a post scraped without its submitter for a job: %+v", post)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package filter provides composable filters for selecting HackerNews posts after scraping.
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// A Filter reports whether a post should be kept.
+type Filter func(hnscraper.Post) bool
+
+// ByMinScore keeps posts with at least the given score.
+func ByMinScore(score int) Filter {
+	return func(p hnscraper.Post) bool {
+		return p.Score >= score
+	}
+}
+
+// ByDomain keeps posts linking to the domain or one of its subdomains, ie. "github.com" also keeps "gist.github.com".
+func ByDomain(domain string) Filter {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+	return func(p hnscraper.Post) bool {
+		postDomain := p.Domain()
+		return postDomain == domain || strings.HasSuffix(postDomain, "."+domain)
+	}
+}
+
+// ByAuthor keeps posts submitted by the user.
+func ByAuthor(author string) Filter {
+	return func(p hnscraper.Post) bool {
+		return p.By == author
+	}
+}
+
+// TitleMatches keeps posts whose title matches the regular expression.
+func TitleMatches(re *regexp.Regexp) Filter {
+	return func(p hnscraper.Post) bool {
+		return re.MatchString(p.Title)
+	}
+}
+
+// ByLang keeps posts whose Post.Lang is one of the languages, ie. "en".
+// Post.Lang is only set by enrichment such as pipeline.Language.
+func ByLang(langs ...string) Filter {
+	return func(p hnscraper.Post) bool {
+		for _, lang := range langs {
+			if p.Lang == lang {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByTag keeps posts tagged with the tag by a hnscraper.Tagger.
+func ByTag(tag string) Filter {
+	return func(p hnscraper.Post) bool {
+		for _, t := range p.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Since keeps posts submitted at or after the given time.
+func Since(t time.Time) Filter {
+	return func(p hnscraper.Post) bool {
+		return !p.TimePosted.Before(t)
+	}
+}
+
+// And keeps posts that every filter keeps.
+func And(filters ...Filter) Filter {
+	return func(p hnscraper.Post) bool {
+		for _, f := range filters {
+			if !f(p) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Or keeps posts that at least one filter keeps.
+func Or(filters ...Filter) Filter {
+	return func(p hnscraper.Post) bool {
+		for _, f := range filters {
+			if f(p) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Posts returns the posts the filter keeps, in their original order.
+func Posts(posts []hnscraper.Post, f Filter) []hnscraper.Post {
+	var kept []hnscraper.Post
+	for _, p := range posts {
+		if f(p) {
+			kept = append(kept, p)
+		}
+	}
+
+	return kept
+}
+
+// Page returns a copy of the page holding only the posts the filter keeps.
+func Page(page hnscraper.Page, f Filter) hnscraper.Page {
+	page.Posts = Posts(page.Posts, f)
+	return page
+}
+
+// Stream forwards the posts the filter keeps from in to the returned channel.
+// The returned channel is closed once in is closed.
+func Stream(in <-chan hnscraper.Post, f Filter) <-chan hnscraper.Post {
+	out := make(chan hnscraper.Post)
+	go func() {
+		defer close(out)
+		for p := range in {
+			if f(p) {
+				out <- p
+			}
+		}
+	}()
+
+	return out
+}
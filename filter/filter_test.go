@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+var now = time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+
+var posts = []hnscraper.Post{
+	{Rank: 1, Title: "Show HN: A Go scraper", Score: 120, By: "alice", URL: "https://github.com/a/b", TimePosted: now},
+	{Rank: 2, Title: "Rust 2.0", Score: 40, By: "bob", URL: "https://www.rust-lang.org/", TimePosted: now.Add(-2 * time.Hour), Lang: "de"},
+	{Rank: 3, Title: "Gists are neat", Score: 75, By: "alice", URL: "https://gist.github.com/c", TimePosted: now.Add(-5 * time.Hour), Tags: []string{"git"}},
+	{Rank: 4, Title: "Ask HN: Anyone else?", Score: 10, By: "carol", URL: "item?id=4", TimePosted: now.Add(-time.Hour)},
+}
+
+func ranks(posts []hnscraper.Post) []int {
+	var result []int
+	for _, p := range posts {
+		result = append(result, p.Rank)
+	}
+
+	return result
+}
+
+func TestFilters(t *testing.T) {
+	cases := map[string]struct {
+		f    Filter
+		want []int
+	}{
+		"min score": {ByMinScore(75), []int{1, 3}},
+		"domain":    {ByDomain("github.com"), []int{1, 3}},
+		"www":       {ByDomain("rust-lang.org"), []int{2}},
+		"author":    {ByAuthor("alice"), []int{1, 3}},
+		"title":     {TitleMatches(regexp.MustCompile(`^(Show|Ask) HN`)), []int{1, 4}},
+		"since":     {Since(now.Add(-time.Hour)), []int{1, 4}},
+		"lang":      {ByLang("fr", "de"), []int{2}},
+		"tag":       {ByTag("git"), []int{3}},
+		"and":       {And(ByAuthor("alice"), ByMinScore(100)), []int{1}},
+		"or":        {Or(ByAuthor("bob"), ByAuthor("carol")), []int{2, 4}},
+	}
+
+	for name, c := range cases {
+		got := ranks(Posts(posts, c.f))
+		if len(got) != len(c.want) {
+			t.Error(name, ": kept ranks ", got, " instead of ", c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Error(name, ": kept ranks ", got, " instead of ", c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	page := hnscraper.Page{Posts: posts, Num: 2, Retrieved: now}
+	filtered := Page(page, ByMinScore(100))
+
+	if filtered.Num != 2 || !filtered.Retrieved.Equal(now) {
+		t.Error("did not preserve page metadata")
+	}
+	if len(filtered.Posts) != 1 || len(page.Posts) != len(posts) {
+		t.Error("filtered ", len(filtered.Posts), " posts from a page of ", len(page.Posts))
+	}
+}
+
+func TestStream(t *testing.T) {
+	in := make(chan hnscraper.Post)
+	go func() {
+		defer close(in)
+		for _, p := range posts {
+			in <- p
+		}
+	}()
+
+	var got []hnscraper.Post
+	for p := range Stream(in, ByAuthor("alice")) {
+		got = append(got, p)
+	}
+
+	if len(got) != 2 {
+		t.Error("streamed ", len(got), " posts instead of 2")
+	}
+}
@@ -4,11 +4,11 @@ go 1.17
 
 require (
 	github.com/antchfx/htmlquery v1.2.4
+	github.com/antchfx/xpath v1.2.0
 	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f
 )
 
 require (
-	github.com/antchfx/xpath v1.2.0 // indirect
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
 	golang.org/x/text v0.3.6 // indirect
 )
@@ -0,0 +1,92 @@
+package hnscraper
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+)
+
+// The gob encodings are a compact binary alternative to JSON for storing and shipping scraped data.
+// Each function writes or reads a single gob stream, so a stream must be written by one call
+// rather than appended to by several.
+
+// EncodePages writes the pages to w in gob's binary encoding.
+func EncodePages(w io.Writer, pages []Page) error {
+	enc := gob.NewEncoder(w)
+	for _, page := range pages {
+		if err := enc.Encode(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodePages reads pages written by EncodePages until the end of r.
+func DecodePages(r io.Reader) ([]Page, error) {
+	var pages []Page
+	dec := gob.NewDecoder(r)
+	for {
+		var page Page
+		if err := dec.Decode(&page); errors.Is(err, io.EOF) {
+			return pages, nil
+		} else if err != nil {
+			return pages, err
+		}
+		pages = append(pages, page)
+	}
+}
+
+// EncodeItems writes the items, including their comment trees, to w in gob's binary encoding.
+func EncodeItems(w io.Writer, items []Item) error {
+	enc := gob.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeItems reads items written by EncodeItems until the end of r.
+func DecodeItems(r io.Reader) ([]Item, error) {
+	var items []Item
+	dec := gob.NewDecoder(r)
+	for {
+		var item Item
+		if err := dec.Decode(&item); errors.Is(err, io.EOF) {
+			return items, nil
+		} else if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
+
+// EncodeComments writes the comments to w in gob's binary encoding.
+func EncodeComments(w io.Writer, comments []Comment) error {
+	enc := gob.NewEncoder(w)
+	for _, comment := range comments {
+		if err := enc.Encode(comment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeComments reads comments written by EncodeComments until the end of r.
+func DecodeComments(r io.Reader) ([]Comment, error) {
+	var comments []Comment
+	dec := gob.NewDecoder(r)
+	for {
+		var comment Comment
+		if err := dec.Decode(&comment); errors.Is(err, io.EOF) {
+			return comments, nil
+		} else if err != nil {
+			return comments, err
+		}
+		comments = append(comments, comment)
+	}
+}
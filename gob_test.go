@@ -0,0 +1,78 @@
+package hnscraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGobPages(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithUTC())
+	pages, err := s.ScrapeMultPages(1, 2)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePages(&buf, pages); err != nil {
+		t.Fatal("error: ", err)
+	}
+	jsonData, err := json.Marshal(pages)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if buf.Len() >= len(jsonData) {
+		t.Error("gob encoding took ", buf.Len(), " bytes, no smaller than the ", len(jsonData), " bytes of JSON")
+	}
+
+	decoded, err := DecodePages(&buf)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(decoded) != len(pages) {
+		t.Fatal("decoded ", len(decoded), " pages instead of ", len(pages))
+	}
+	for i := range pages {
+		if decoded[i].Num != pages[i].Num || !decoded[i].Retrieved.Equal(pages[i].Retrieved) || len(decoded[i].Posts) != len(pages[i].Posts) {
+			t.Fatal("decoded page ", decoded[i].Num, " differently")
+		}
+		for j := range pages[i].Posts {
+			if changed := decoded[i].Posts[j].Changed(pages[i].Posts[j]); len(changed) > 0 {
+				t.Error("decoded post ", pages[i].Posts[j].ID, " with different ", changed)
+			}
+		}
+	}
+}
+
+func TestGobItems(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/item.html"), WithUTC())
+	item, err := s.ScrapeItem(28719320)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeItems(&buf, []Item{item}); err != nil {
+		t.Fatal("error: ", err)
+	}
+	decoded, err := DecodeItems(&buf)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(decoded) != 1 || decoded[0].Text != item.Text || len(decoded[0].Comments) != len(item.Comments) {
+		t.Fatal("decoded ", decoded, " instead of ", item)
+	}
+	got, want := decoded[0].Comments[0], item.Comments[0]
+	if got.Text != want.Text || len(got.Replies) != len(want.Replies) || !got.TimePosted.Equal(want.TimePosted) {
+		t.Error("decoded comment ", got, " instead of ", want)
+	}
+
+	buf.Reset()
+	if err := EncodeComments(&buf, item.Comments); err != nil {
+		t.Fatal("error: ", err)
+	}
+	comments, err := DecodeComments(&buf)
+	if err != nil || len(comments) != len(item.Comments) {
+		t.Error("decoded ", len(comments), " comments instead of ", len(item.Comments), ": ", err)
+	}
+}
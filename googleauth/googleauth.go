@@ -0,0 +1,170 @@
+// Package googleauth gets OAuth2 access tokens for Google APIs from service-account keys.
+package googleauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// Credentials are a service account's key, as downloaded from the Google Cloud console.
+// Tokens are cached and refreshed shortly before they expire. Credentials are safe for concurrent use.
+type Credentials struct {
+	Email    string          // The service account's email address
+	Key      *rsa.PrivateKey // The service account's private key
+	TokenURL string          // Where tokens are requested, the Google token endpoint if empty
+	Scopes   []string        // The OAuth2 scopes tokens are requested for
+	Client   *http.Client    // The client tokens are requested with, nil for http.DefaultClient
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// keyFile is the JSON key file of a service account.
+type keyFile struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// LoadCredentials reads a service account's JSON key file, requesting tokens for the scopes.
+func LoadCredentials(path string, scopes ...string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCredentials(data, scopes...)
+}
+
+// ParseCredentials parses a service account's JSON key, requesting tokens for the scopes.
+func ParseCredentials(data []byte, scopes ...string) (*Credentials, error) {
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, err
+	}
+	if kf.Type != "service_account" || kf.ClientEmail == "" {
+		return nil, errors.New("not a service account key")
+	}
+
+	block, _ := pem.Decode([]byte(kf.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account key has no PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		// Older keys are PKCS #1
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account key is not an RSA key")
+	}
+
+	return &Credentials{Email: kf.ClientEmail, Key: key, TokenURL: kf.TokenURI, Scopes: scopes}, nil
+}
+
+// Token returns an access token, requesting a new one if the cached token is missing or about to expire.
+func (c *Credentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires.Add(-time.Minute)) {
+		return c.token, nil
+	}
+
+	tokenURL := c.TokenURL
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	assertion, err := c.assertion(tokenURL, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint responded with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("token endpoint returned no access token")
+	}
+
+	c.token = body.AccessToken
+	c.expires = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// assertion builds the signed JWT exchanged for an access token.
+func (c *Credentials) assertion(aud string, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   c.Email,
+		"scope": strings.Join(c.Scopes, " "),
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,97 @@
+package googleauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// keyJSON creates a service account key file for a new key, pointing at the token URL.
+func keyJSON(t *testing.T, tokenURL string) ([]byte, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "scraper@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURL,
+	})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	return data, key
+}
+
+func TestToken(t *testing.T) {
+	var requests int32
+	var key *rsa.PrivateKey
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || len(parts) != 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var c struct{ Iss, Scope string }
+		json.Unmarshal(claims, &c)
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig) != nil ||
+			c.Iss != "scraper@example.iam.gserviceaccount.com" || c.Scope != "a b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"access_token": "secret", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	data, k := keyJSON(t, server.URL)
+	key = k
+	creds, err := ParseCredentials(data, "a", "b")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := creds.Token(context.Background())
+		if err != nil {
+			t.Fatal("error: ", err)
+		}
+		if token != "secret" {
+			t.Error("returned token ", token)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Error("requested ", n, " tokens instead of caching the first")
+	}
+}
+
+func TestParseCredentialsInvalid(t *testing.T) {
+	for _, data := range []string{`{}`, `{"type": "service_account", "client_email": "a@b", "private_key": "nope"}`, `not json`} {
+		if _, err := ParseCredentials([]byte(data)); err == nil {
+			t.Error("parsed invalid key ", data)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package hnscraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A JobEntry is a job posted as a top-level comment in a monthly "Ask HN: Who is hiring?" thread.
+// Entries are parsed from the conventional "COMPANY | LOCATION | ROLE | ..." header on the comment's first line.
+type JobEntry struct {
+	Comment  Comment  // The comment the job was posted in
+	Company  string   // The first header field
+	Location string   // The second header field
+	Role     string   // The third header field, often one or more job titles
+	Header   []string // Every header field, for posts that don't follow the convention exactly
+	Remote   bool     // Whether the header mentions remote work
+	Onsite   bool     // Whether the header mentions onsite, in-office, or hybrid work
+	Details  string   // The rest of the comment after the header
+}
+
+var (
+	remoteRegexp = regexp.MustCompile(`(?i)\bremote\b`)
+	onsiteRegexp = regexp.MustCompile(`(?i)\b(on-?site|on site|in-office|in office|hybrid)\b`)
+)
+
+// ScrapeHiring scrapes a "Who is hiring?" thread, returning a JobEntry for every top-level comment with a job header.
+func ScrapeHiring(itemID int) ([]JobEntry, error) {
+	return defaultScraper.ScrapeHiring(itemID)
+}
+
+// ScrapeHiring scrapes a "Who is hiring?" thread, returning a JobEntry for every top-level comment with a job header.
+func (s *Scraper) ScrapeHiring(itemID int) ([]JobEntry, error) {
+	item, err := s.ScrapeItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JobEntry
+	for _, comment := range item.Comments {
+		if entry, ok := ParseJobEntry(comment); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// ParseJobEntry parses a comment as a job posting.
+// It reports false if the comment's first line isn't a header of at least two '|'-separated fields.
+func ParseJobEntry(comment Comment) (JobEntry, bool) {
+	var entry JobEntry
+
+	lines := strings.SplitN(comment.Text, "\n", 2)
+	if !strings.Contains(lines[0], "|") {
+		return entry, false
+	}
+
+	var header []string
+	for _, field := range strings.Split(lines[0], "|") {
+		if field = strings.TrimSpace(field); field != "" {
+			header = append(header, field)
+		}
+	}
+	if len(header) < 2 {
+		return entry, false
+	}
+
+	entry = JobEntry{
+		Comment:  comment,
+		Company:  header[0],
+		Location: header[1],
+		Header:   header,
+		Remote:   remoteRegexp.MatchString(lines[0]),
+		Onsite:   onsiteRegexp.MatchString(lines[0]),
+	}
+	if len(header) > 2 {
+		entry.Role = header[2]
+	}
+	if len(lines) > 1 {
+		entry.Details = strings.TrimSpace(lines[1])
+	}
+
+	return entry, true
+}
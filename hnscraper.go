@@ -1,25 +1,42 @@
 package hnscraper
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
 	"golang.org/x/net/html"
 )
 
 // A Post is a single HackerNews post and the attributes associated with it.
 type Post struct {
-	Rank        int       // The rank of the post, ie. rank 2 means it's the second highest post on the site
-	Title       string    // The title of the post
-	Score       int       // How many 'points' the post has received from voting
-	By          string    // The username of the user that submitted the post
-	URL         string    // The url link that the post is linking to
-	NumComments int       // How many comments were made on the post at the time of access
-	TimePosted  time.Time // Timestamp when the post was submitted
+	ID           int        // The HackerNews item ID, which identifies the post across pages and scrapes
+	Rank         int        // The rank of the post, ie. rank 2 means it's the second highest post on the site
+	Title        string     // The title of the post, normalized for display
+	RawTitle     string     // The title exactly as it appeared on the page
+	Score        int        // How many 'points' the post has received from voting
+	By           string     // The username of the user that submitted the post
+	URL          string     // The url link that the post is linking to
+	NumComments  int        // How many comments were made on the post at the time of access
+	TimePosted   time.Time  // Timestamp when the post was submitted
+	TimeApprox   bool       // Whether TimePosted was estimated from the relative age, ie. "3 hours ago"
+	Pinned       bool       // Whether the post was pinned to the listing without a rank, ie. an announcement
+	Promoted     bool       // Whether the post was placed by HN rather than voted up, ie. a YC job ad or launch
+	Job          bool       // Whether the post is a job ad, which listings show without a submitter or score
+	SecondChance bool       // Whether the post looks re-upped by HN's second-chance pool, see Scraper.ConfirmSecondChance
+	AuthorInfo   AuthorInfo // The submitter's karma and account age, only set by enrichment
+	Lang         string     // The ISO 639-1 code of the title's language, ie. "en", only set by enrichment
+	Tags         []string   // The topic tags matching the post, sorted, only set by a Tagger
+	Hidden       bool       // Whether the logged-in account has hidden the post, see WithSession
+	HideAuth     string     // The auth token of the post's hide link, only set when logged in
+	CommentsURL  string     // The link to the post's discussion, ie. "https://news.ycombinator.com/item?id=28888001"
 }
 
 // A Page is an entire page on HackerNews.
@@ -28,45 +45,180 @@ type Page struct {
 	Posts     []Post    // All the posts on the page
 	Num       int       // The page number. Page 1 is the homepage/mainpage
 	Retrieved time.Time // The time the request for the page was completed
+	Meta      PageMeta  // How the page was served, zero for pages that weren't fetched, ie. replayed from an archive
+	Stale     bool      // Whether the page is an earlier snapshot served while HackerNews was down, see WithStaleCache
 }
 
-const hackernewsURL = "https://news.ycombinator.com/news?p="
+// PageMeta describes the HTTP response a page was parsed from.
+type PageMeta struct {
+	Status   int           // The HTTP status code
+	Latency  time.Duration // How long the response took to arrive, from sending the request to receiving its headers
+	FinalURL string        // The URL the page was served from, after any redirects
+	Bytes    int           // The size of the response body
+}
+
+// Permalink returns the absolute link the post's title points to: the article for link posts,
+// and the discussion for self posts such as Ask HN, whose URL is relative to HackerNews.
+func (p Post) Permalink() string {
+	u, err := url.Parse(p.URL)
+	if err != nil || u.IsAbs() {
+		return p.URL
+	}
+	base, _ := url.Parse(hackernewsURL)
+
+	return base.ResolveReference(u).String()
+}
+
+// itemURL returns the link to an item's page on HackerNews.
+func itemURL(id int) string {
+	return hackernewsURL + "item?id=" + strconv.Itoa(id)
+}
+
+// Domain returns the host the post links to without a leading "www.", ie. "github.com".
+// Posts that link to HackerNews itself, such as Ask HN, return an empty string.
+func (p Post) Domain() string {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// The expressions are compiled once rather than looked up in htmlquery's shared cache on every row
+var (
+	listTableExpr    = xpath.MustCompile("//table[contains(@class, 'itemlist')]")
+	listRowsExpr     = xpath.MustCompile("//table[contains(@class, 'itemlist')]/tbody/tr")
+	subtextExpr      = xpath.MustCompile("/td[contains(@class, 'subtext')]")
+	titleExpr        = xpath.MustCompile("/td/a")
+	rankExpr         = xpath.MustCompile("/td/span[contains(@class, 'rank')]")
+	urlExpr          = xpath.MustCompile("/td/a[contains(@class, 'titlelink')]")
+	authorExpr       = xpath.MustCompile("/a[contains(@class, 'hnuser')]")
+	scoreExpr        = xpath.MustCompile("/span[contains(@class, 'score')]")
+	commentsLinkExpr = xpath.MustCompile("/a[starts-with(@href, 'item?id=')]")
+	ageExpr          = xpath.MustCompile("/span[contains(@class, 'age')]")
+
+	nonDigitsRegexp = regexp.MustCompile("[^0-9]+")
+)
+
+const hackernewsURL = "https://news.ycombinator.com/"
+
+// Version is the version of the package, recorded in the datasets it exports.
+const Version = "0.1.0"
+
+// SelectorVersion is bumped whenever the way pages are parsed changes, ie. a selector is updated
+// for new markup, so exported datasets record which parser produced them.
+const SelectorVersion = 1
 
 // ScrapePage scrapes a single page from HackerNews.
 // Use '1' for the homepage/mainpage.
 func ScrapePage(pageNum int) (Page, error) {
+	return defaultScraper.ScrapePage(pageNum)
+}
+
+// ScrapeMultPages scrapes all pages from the starting page number to the ending page number, inclusive.
+func ScrapeMultPages(startPage, endPage int) ([]Page, error) {
+	return defaultScraper.ScrapeMultPages(startPage, endPage)
+}
+
+// ScrapePage scrapes a single page from HackerNews.
+// Use '1' for the homepage/mainpage.
+func (s *Scraper) ScrapePage(pageNum int) (Page, error) {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	return s.scrapePage(ctx, pageNum)
+}
+
+// ScrapePageContext scrapes a single page like ScrapePage, giving up when ctx is done.
+func (s *Scraper) ScrapePageContext(ctx context.Context, pageNum int) (Page, error) {
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	return s.scrapePage(ctx, pageNum)
+}
+
+func (s *Scraper) scrapePage(ctx context.Context, pageNum int) (Page, error) {
+	if s.coalescer != nil {
+		return s.coalescer.do(ctx, pageNum, s.loadPage)
+	}
+
+	return s.loadPage(ctx, pageNum)
+}
+
+// loadPage scrapes a listing page, falling back to the stale cache if HackerNews can't be reached.
+func (s *Scraper) loadPage(ctx context.Context, pageNum int) (Page, error) {
 	var page Page
-	var posts []Post
 
 	if pageNum < 1 {
 		return page, errors.New("page number must be a positive integer")
 	}
 
-	doc, err := htmlquery.LoadURL(hackernewsURL + strconv.Itoa(pageNum))
-	retrievedTime := time.Now()
-
+	meta, err := s.fetchPage(ctx, s.baseURL+"news?p="+strconv.Itoa(pageNum), func(ctx context.Context, doc *html.Node) (err error) {
+		page, err = s.parsePage(ctx, doc, pageNum, time.Now())
+		return err
+	})
+	page.Meta = meta
 	if err != nil {
+		if stale, ok := s.stalePage(ctx, pageNum, err); ok {
+			return stale, nil
+		}
 		return page, err
 	}
+	s.cachePage(page)
+
+	return page, nil
+}
+
+func (s *Scraper) parsePage(ctx context.Context, doc *html.Node, pageNum int, retrievedTime time.Time) (Page, error) {
+	var page Page
+	var posts []Post
 
-	listNodes := htmlquery.Find(doc, "//table[contains(@class, 'itemlist')]/tbody/tr")
+	listNodes := htmlquery.QuerySelectorAll(doc, listRowsExpr)
+	if len(listNodes) == 0 {
+		if isNoprocrast(doc) {
+			return page, ErrNoprocrast
+		}
+		// Listings past the last page are empty, but still have the table
+		if htmlquery.QuerySelector(doc, listTableExpr) == nil {
+			return page, stageError("listing", errors.New(errorMsg))
+		}
+	}
 
+	needsSubtext := s.needsSubtext()
 	for i := 0; i < len(listNodes)-2; i += 3 {
-		subtext := htmlquery.FindOne(listNodes[i+1], "/td[contains(@class, 'subtext')]")
-		post, err := getPost(listNodes[i], subtext)
+		var subtext *html.Node
+		if needsSubtext {
+			if subtext = htmlquery.QuerySelector(listNodes[i+1], subtextExpr); subtext == nil {
+				return page, stageError("subtext", errors.New(errorMsg))
+			}
+		}
+		post, err := s.getPost(ctx, listNodes[i], subtext, retrievedTime)
 		if err != nil {
 			return page, err
 		}
 
+		// Only listings rank posts, so a missing rank means the post was pinned
+		post.Pinned = s.wants(FieldRank) && post.Rank == 0
+		post.Job = s.wants(FieldAuthor, FieldScore) && post.By == "" && post.Score == 0
+		post.Promoted = s.wants(FieldAuthor, FieldScore) && isPromoted(post)
+		if s.skipPinned && (post.Pinned || post.Promoted) {
+			continue
+		}
+
 		posts = append(posts, post)
 	}
+	if s.wants(FieldRank, FieldTimePosted) {
+		markSecondChance(posts, retrievedTime)
+	}
 
 	page = Page{Posts: posts, Num: pageNum, Retrieved: retrievedTime}
 	return page, nil
 }
 
 // ScrapeMultPages scrapes all pages from the starting page number to the ending page number, inclusive.
-func ScrapeMultPages(startPage, endPage int) ([]Page, error) {
+// If a page fails, the pages scraped before it are returned along with a *PagesError saying which page failed.
+func (s *Scraper) ScrapeMultPages(startPage, endPage int) ([]Page, error) {
 	var pages []Page
 
 	if startPage < 1 || endPage < 1 {
@@ -76,10 +228,14 @@ func ScrapeMultPages(startPage, endPage int) ([]Page, error) {
 			"starting page number cannot be larger than ending page number")
 	}
 
+	// The operation timeout covers every page, not each one
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
 	for i := startPage; i <= endPage; i++ {
-		page, err := ScrapePage(i)
+		page, err := s.scrapePage(ctx, i)
 		if err != nil {
-			return pages, err
+			return pages, &PagesError{Page: i, Err: err}
 		}
 
 		pages = append(pages, page)
@@ -88,62 +244,169 @@ func ScrapeMultPages(startPage, endPage int) ([]Page, error) {
 	return pages, nil
 }
 
-func getPost(titleNode, subtextNode *html.Node) (Post, error) {
-	var post Post
+// ScrapeFrontDay scrapes a page of the front page as it stood on a past day. See Scraper.ScrapeFrontDay.
+func ScrapeFrontDay(day time.Time, pageNum int) (Page, error) {
+	return defaultScraper.ScrapeFrontDay(day, pageNum)
+}
 
-	title, err := getTitle(titleNode)
-	if err != nil {
-		return post, err
-	}
+// ScrapeFrontDay scrapes a page of HackerNews's past front page for the day, ie. the posts that reached
+// the front page on the date day has in its own time zone, ranked as they were that day. Use '1' for the day's top posts.
+// Later pages past the end of the day's listing are empty.
+func (s *Scraper) ScrapeFrontDay(day time.Time, pageNum int) (Page, error) {
+	var page Page
 
-	rank, err := getRank(titleNode)
-	if err != nil {
-		return post, err
+	if pageNum < 1 {
+		return page, errors.New("page number must be a positive integer")
 	}
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
 
-	url, err := getURL(titleNode)
-	if err != nil {
-		return post, err
-	}
+	pageURL := s.baseURL + "front?day=" + day.Format("2006-01-02") + "&p=" + strconv.Itoa(pageNum)
+	meta, err := s.fetchPage(ctx, pageURL, func(ctx context.Context, doc *html.Node) (err error) {
+		page, err = s.parsePage(ctx, doc, pageNum, time.Now())
+		return err
+	})
+	page.Meta = meta
 
-	author, err := getAuthor(subtextNode)
-	if err != nil {
-		return post, err
+	return page, err
+}
+
+// ScrapeFrontPageTop scrapes the top n posts from HackerNews. See Scraper.ScrapeFrontPageTop.
+func ScrapeFrontPageTop(n int) ([]Post, error) {
+	return defaultScraper.ScrapeFrontPageTop(n)
+}
+
+// ScrapeUntil scrapes posts from HackerNews in rank order until one doesn't match. See Scraper.ScrapeUntil.
+func ScrapeUntil(match func(Post) bool) ([]Post, error) {
+	return defaultScraper.ScrapeUntil(match)
+}
+
+// ScrapeFrontPageTop scrapes the top n posts from HackerNews, in rank order, scraping as many pages as it takes.
+// Fewer posts are returned if the listing runs out first.
+// If a page fails, the posts scraped before it are returned along with a *PagesError saying which page failed.
+func (s *Scraper) ScrapeFrontPageTop(n int) ([]Post, error) {
+	if n < 1 {
+		return nil, errors.New("number of posts must be a positive integer")
 	}
 
-	points, err := getPoints(subtextNode)
-	if err != nil {
-		return post, err
+	var posts []Post
+	err := s.scrapeWhile(func(post Post) bool {
+		posts = append(posts, post)
+		return len(posts) < n
+	})
+
+	return posts, err
+}
+
+// ScrapeUntil scrapes posts from HackerNews in rank order, scraping as many pages as it takes, until a post doesn't
+// match, ie. one with a score under 50 or submitted over a day ago. The posts before it are returned.
+// If a page fails, the posts scraped before it are returned along with a *PagesError saying which page failed.
+func (s *Scraper) ScrapeUntil(match func(Post) bool) ([]Post, error) {
+	var posts []Post
+	err := s.scrapeWhile(func(post Post) bool {
+		if !match(post) {
+			return false
+		}
+		posts = append(posts, post)
+		return true
+	})
+
+	return posts, err
+}
+
+// scrapeWhile hands every post to fn in rank order, scraping page after page until fn returns false
+// or the listing runs out.
+func (s *Scraper) scrapeWhile(fn func(Post) bool) error {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	for i := 1; ; i++ {
+		page, err := s.scrapePage(ctx, i)
+		if err != nil {
+			return &PagesError{Page: i, Err: err}
+		}
+		// Listings past the last page are empty
+		if len(page.Posts) == 0 {
+			return nil
+		}
+		for _, post := range page.Posts {
+			if !fn(post) {
+				return nil
+			}
+		}
 	}
+}
 
-	numComments, err := getNumComments(subtextNode)
-	if err != nil {
-		return post, err
+// A PagesError reports which page stopped a multi-page scrape. The pages before it were scraped successfully.
+type PagesError struct {
+	Page int   // The number of the page that failed
+	Err  error // Why it failed
+}
+
+func (e *PagesError) Error() string {
+	return fmt.Sprintf("could not scrape page %d: %v", e.Page, e.Err)
+}
+
+func (e *PagesError) Unwrap() error {
+	return e.Err
+}
+
+func (s *Scraper) getPost(ctx context.Context, titleNode, subtextNode *html.Node, retrieved time.Time) (post Post, err error) {
+	_, span := s.startSpan(ctx, SpanRow)
+	defer func() {
+		span.SetInt("id", post.ID)
+		span.End(err)
+	}()
+
+	row := Row{Title: titleNode, Subtext: subtextNode, Retrieved: retrieved, Location: s.location}
+	for field := Field(0); field < numFields; field++ {
+		if !s.wants(field) {
+			continue
+		}
+		extract, ok := s.extractors[field]
+		if !ok {
+			extract = DefaultExtractor(field)
+		}
+		if err := extract(row, &post); err != nil {
+			return Post{}, stageError(field.String(), err)
+		}
 	}
 
-	timePosted, err := getTimePosted(subtextNode)
-	if err != nil {
-		return post, err
+	if subtextNode != nil {
+		post.Hidden, post.HideAuth = getHideLink(subtextNode)
+	}
+	if post.ID != 0 {
+		post.CommentsURL = itemURL(post.ID)
 	}
 
-	post = Post{
-		Title:       title,
-		Score:       points,
-		Rank:        rank,
-		By:          author,
-		URL:         url,
-		NumComments: numComments,
-		TimePosted:  timePosted,
+	post.Title = post.RawTitle
+	if !s.rawTitles {
+		post.Title = normalizeTitle(post.RawTitle)
 	}
 
 	return post, nil
 }
 
+// isPromoted reports whether the post was placed on the listing by HN: job ads have neither a score nor a submitter,
+// and YC launches link to the launches directory.
+func isPromoted(post Post) bool {
+	if post.By == "" && post.Score == 0 {
+		return true
+	}
+
+	u, err := url.Parse(post.URL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return host == "ycombinator.com" && strings.HasPrefix(u.Path, "/launches/")
+}
+
 const errorMsg = "could not process: page formatted unexpectedly"
 
 func getTitle(node *html.Node) (string, error) {
 	title := ""
-	titleQuery := htmlquery.Find(node, "/td/a")
+	titleQuery := htmlquery.QuerySelectorAll(node, titleExpr)
 	if len(titleQuery) != 1 {
 		return title, errors.New(errorMsg)
 	}
@@ -152,14 +415,33 @@ func getTitle(node *html.Node) (string, error) {
 	return title, nil
 }
 
+// normalizeTitle decodes entities that were escaped twice and collapses stray whitespace.
+func normalizeTitle(title string) string {
+	title = html.UnescapeString(title)
+	return strings.Join(strings.Fields(title), " ")
+}
+
+func getID(node *html.Node) (int, error) {
+	id, err := strconv.Atoi(htmlquery.SelectAttr(node, "id"))
+	if err != nil {
+		return id, errors.New(errorMsg)
+	}
+
+	return id, nil
+}
+
 func getRank(node *html.Node) (int, error) {
 	rank := 0
-	rankQuery := htmlquery.Find(node, "/td/span[contains(@class, 'rank')]")
+	rankQuery := htmlquery.QuerySelectorAll(node, rankExpr)
 	if len(rankQuery) != 1 {
 		return rank, errors.New(errorMsg)
 	}
-	rankStr := htmlquery.InnerText(rankQuery[0])
-	rank, err := strconv.Atoi(rankStr[:len(rankStr)-1])
+	rankStr := strings.TrimSuffix(strings.TrimSpace(htmlquery.InnerText(rankQuery[0])), ".")
+	// Item pages show a post without a rank
+	if rankStr == "" {
+		return rank, nil
+	}
+	rank, err := strconv.Atoi(rankStr)
 	if err != nil {
 		return rank, err
 	}
@@ -169,7 +451,7 @@ func getRank(node *html.Node) (int, error) {
 
 func getURL(node *html.Node) (string, error) {
 	url := ""
-	urlQuery := htmlquery.Find(node, "/td/a[contains(@class, 'titlelink')]")
+	urlQuery := htmlquery.QuerySelectorAll(node, urlExpr)
 	if len(urlQuery) != 1 {
 		return url, errors.New(errorMsg)
 	}
@@ -180,7 +462,7 @@ func getURL(node *html.Node) (string, error) {
 
 func getAuthor(node *html.Node) (string, error) {
 	author := ""
-	authorQuery := htmlquery.Find(node, "/a[contains(@class, 'hnuser')]")
+	authorQuery := htmlquery.QuerySelectorAll(node, authorExpr)
 	if len(authorQuery) == 1 {
 		author = htmlquery.InnerText(authorQuery[0])
 	}
@@ -190,13 +472,19 @@ func getAuthor(node *html.Node) (string, error) {
 
 func getPoints(node *html.Node) (int, error) {
 	points := 0
-	pointsQuery := htmlquery.Find(node, "/span[contains(@class, 'score')]")
-	if len(pointsQuery) != 1 {
+	pointsQuery := htmlquery.QuerySelectorAll(node, scoreExpr)
+	// Job postings don't display a score
+	if len(pointsQuery) == 0 {
+		return points, nil
+	} else if len(pointsQuery) != 1 {
 		return points, errors.New(errorMsg)
 	}
-	pointsStr := htmlquery.InnerText(pointsQuery[0])
-	points, err := strconv.Atoi(
-		strings.TrimSpace(strings.ReplaceAll(pointsStr, "points", "")))
+	// Handles both "1 point" and "42 points"
+	pointsFields := strings.Fields(htmlquery.InnerText(pointsQuery[0]))
+	if len(pointsFields) == 0 {
+		return points, errors.New(errorMsg)
+	}
+	points, err := strconv.Atoi(pointsFields[0])
 	if err != nil {
 		return points, err
 	}
@@ -206,28 +494,26 @@ func getPoints(node *html.Node) (int, error) {
 
 func getNumComments(node *html.Node) (int, error) {
 	num := 0
-	reg, err := regexp.Compile("[^0-9]+")
-	if err != nil {
-		return num, err
-	}
-
 	commentsStr := ""
-	commentsQuery := htmlquery.Find(node, "/a]")
+	// Only the discussion link points at the item, which rules out the
+	// hide/past/flag links and usernames that happen to contain "comment"
+	commentsQuery := htmlquery.QuerySelectorAll(node, commentsLinkExpr)
 	for _, query := range commentsQuery {
-		linkStr := htmlquery.InnerText(query)
+		linkStr := strings.TrimSpace(htmlquery.InnerText(query))
 		// No comments added to post
-		if strings.Contains(linkStr, "discuss") {
+		if linkStr == "discuss" {
 			return 0, nil
-		} else if strings.Contains(linkStr, "comment") {
+		} else if strings.HasSuffix(linkStr, "comment") || strings.HasSuffix(linkStr, "comments") {
 			// Extract the number of comments
-			commentsStr = reg.ReplaceAllLiteralString(linkStr, "")
+			commentsStr = nonDigitsRegexp.ReplaceAllLiteralString(linkStr, "")
 		}
 	}
+	// Job postings don't have a discussion link
 	if commentsStr == "" {
-		return num, errors.New(errorMsg)
+		return num, nil
 	}
 
-	num, err = strconv.Atoi(commentsStr)
+	num, err := strconv.Atoi(commentsStr)
 	if err != nil {
 		return num, err
 	}
@@ -235,17 +521,76 @@ func getNumComments(node *html.Node) (int, error) {
 	return num, nil
 }
 
-func getTimePosted(node *html.Node) (time.Time, error) {
+// HN renders the title attribute of a post's age in UTC
+const timeLayout = "2006-01-02T15:04:05"
+
+func getTimePosted(node *html.Node, loc *time.Location) (time.Time, error) {
+	timeQuery := htmlquery.QuerySelectorAll(node, ageExpr)
+	if len(timeQuery) != 1 {
+		return time.Time{}, errors.New(errorMsg)
+	}
+
+	return parseAgeTitle(htmlquery.SelectAttr(timeQuery[0], "title"), loc)
+}
+
+// parseAgeTitle parses the title attribute of a post or comment's age.
+func parseAgeTitle(title string, loc *time.Location) (time.Time, error) {
 	var posted time.Time
-	timeQuery := htmlquery.Find(node, "/span[contains(@class, 'age')]")
+	timeFields := strings.Fields(title)
+	if len(timeFields) == 0 {
+		return posted, errors.New(errorMsg)
+	}
+
+	// Newer markup appends the unix timestamp, which is unambiguous
+	if len(timeFields) > 1 {
+		if epoch, err := strconv.ParseInt(timeFields[1], 10, 64); err == nil {
+			return time.Unix(epoch, 0).In(loc), nil
+		}
+	}
+
+	posted, err := time.ParseInLocation(timeLayout, timeFields[0], time.UTC)
+	if err != nil {
+		return posted, err
+	}
+
+	return posted.In(loc), nil
+}
+
+var relativeUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// getRelativeTime estimates when a post was submitted from its visible age, ie. "3 hours ago".
+func getRelativeTime(node *html.Node, anchor time.Time) (time.Time, error) {
+	timeQuery := htmlquery.QuerySelectorAll(node, ageExpr)
 	if len(timeQuery) != 1 {
+		return time.Time{}, errors.New(errorMsg)
+	}
+
+	return parseRelativeAge(htmlquery.InnerText(timeQuery[0]), anchor)
+}
+
+// parseRelativeAge estimates a time from an age relative to the anchor, ie. "3 hours ago".
+func parseRelativeAge(age string, anchor time.Time) (time.Time, error) {
+	var posted time.Time
+	ageFields := strings.Fields(age)
+	if len(ageFields) != 3 || ageFields[2] != "ago" {
 		return posted, errors.New(errorMsg)
 	}
-	timeStr := htmlquery.SelectAttr(timeQuery[0], "title")
-	posted, err := time.Parse("2006-01-02T15:04:05", timeStr)
+
+	amount, err := strconv.Atoi(ageFields[0])
 	if err != nil {
 		return posted, err
 	}
+	unit, ok := relativeUnits[strings.TrimSuffix(ageFields[1], "s")]
+	if !ok {
+		return posted, errors.New(errorMsg)
+	}
 
+	posted = anchor.Add(-time.Duration(amount) * unit)
 	return posted, nil
 }
@@ -1,9 +1,48 @@
 package hnscraper
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
 )
 
+// newTestScraper creates a Scraper that scrapes a local server using the handler instead of HackerNews.
+func newTestScraper(t testing.TB, handler http.HandlerFunc, opts ...Option) *Scraper {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	s := NewScraper(opts...)
+	s.baseURL = server.URL + "/"
+	return s
+}
+
+// serveFile responds to every request with the contents of the file.
+func serveFile(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, path)
+	}
+}
+
+func parseSubtext(t *testing.T, inner string) *html.Node {
+	doc, err := htmlquery.Parse(strings.NewReader(
+		"<table><tr><td class=\"subtext\">" + inner + "</td></tr></table>"))
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	return htmlquery.FindOne(doc, "//td[contains(@class, 'subtext')]")
+}
+
 func TestScrapePageFail(t *testing.T) {
 	_, err := ScrapePage(0)
 
@@ -27,6 +66,118 @@ func TestScrapePageSuccess(t *testing.T) {
 	}
 }
 
+func TestScrapePageFixture(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithUTC())
+	result, err := s.ScrapePage(1)
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if len(result.Posts) != 3 {
+		t.Error("returned ", len(result.Posts), " posts instead of 3")
+		return
+	}
+
+	first := result.Posts[0]
+	if first.ID != 28888001 || first.Rank != 1 || first.Score != 312 || first.By != "alice" ||
+		first.NumComments != 104 || first.Title != "Show HN: A scraper for & HackerNews" ||
+		!first.TimePosted.Equal(time.Date(2021, 10, 16, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("parsed first post incorrectly: %+v", first)
+	}
+
+	job := result.Posts[2]
+	if job.Score != 0 || job.By != "" || job.NumComments != 0 || !job.Promoted || !job.Job || job.Type() != TypeJob {
+		t.Errorf("parsed job posting incorrectly: %+v", job)
+	}
+}
+
+func TestPostLinks(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"))
+	page, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	for _, post := range page.Posts {
+		if post.CommentsURL != "https://news.ycombinator.com/item?id="+strconv.Itoa(post.ID) {
+			t.Error("linked post ", post.ID, " to discussion ", post.CommentsURL)
+		}
+	}
+	// Self posts link to their discussion
+	if selfPost := page.Posts[1]; selfPost.Permalink() != selfPost.CommentsURL {
+		t.Error("linked self post to ", selfPost.Permalink())
+	}
+	if linkPost := page.Posts[0]; linkPost.Permalink() != linkPost.URL {
+		t.Error("linked post to ", linkPost.Permalink(), " instead of ", linkPost.URL)
+	}
+}
+
+func TestPinnedPosts(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	// Pin the second post by removing its rank, as HN does for announcements
+	pinned := strings.Replace(string(fixture), `<span class="rank">2.</span>`, `<span class="rank"></span>`, 1)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, pinned)
+	}
+
+	page, err := newTestScraper(t, handler).ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(page.Posts) != 3 {
+		t.Fatal("returned ", len(page.Posts), " posts instead of 3")
+	}
+	for i, want := range [][2]bool{{false, false}, {true, false}, {false, true}} {
+		if post := page.Posts[i]; post.Pinned != want[0] || post.Promoted != want[1] {
+			t.Errorf("flagged post %d as pinned %t, promoted %t", post.ID, post.Pinned, post.Promoted)
+		}
+	}
+
+	page, err = newTestScraper(t, handler, WithSkipPinned()).ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].ID != 28888001 {
+		t.Error("kept pinned or promoted posts: ", page.Posts)
+	}
+}
+
+func TestPageMeta(t *testing.T) {
+	info, err := os.Stat("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/news" {
+			http.Redirect(w, r, "/front?"+r.URL.RawQuery, http.StatusFound)
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	})
+
+	page, err := s.ScrapePage(2)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if page.Meta.Status != http.StatusOK {
+		t.Error("recorded status ", page.Meta.Status)
+	}
+	if !strings.HasSuffix(page.Meta.FinalURL, "/front?p=2") {
+		t.Error("recorded final url ", page.Meta.FinalURL)
+	}
+	if page.Meta.Bytes != int(info.Size()) {
+		t.Error("recorded ", page.Meta.Bytes, " bytes instead of ", info.Size())
+	}
+	if page.Meta.Latency <= 0 {
+		t.Error("recorded latency ", page.Meta.Latency)
+	}
+}
+
 func TestScrapeMultPagesFail(t *testing.T) {
 	_, err := ScrapeMultPages(-1, 2)
 
@@ -36,6 +187,29 @@ func TestScrapeMultPagesFail(t *testing.T) {
 	}
 }
 
+func TestScrapeMultPagesPartial(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("p") == "3" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	})
+
+	pages, err := s.ScrapeMultPages(1, 5)
+	var pagesErr *PagesError
+	if !errors.As(err, &pagesErr) || pagesErr.Page != 3 {
+		t.Fatal("returned ", err, " instead of a *PagesError for page 3")
+	}
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) || statusErr.code != http.StatusNotFound {
+		t.Error("didn't wrap the page's own error: ", err)
+	}
+	if len(pages) != 2 || pages[1].Num != 2 {
+		t.Error("returned ", len(pages), " pages instead of the 2 before the failure")
+	}
+}
+
 func TestScrapeMultPagesSuccess(t *testing.T) {
 	result, err := ScrapeMultPages(1, 3)
 
@@ -49,3 +223,125 @@ func TestScrapeMultPagesSuccess(t *testing.T) {
 		t.Error("returned ", numPages, " pages instead of 3")
 	}
 }
+
+func TestGetPoints(t *testing.T) {
+	cases := map[string]int{
+		`<span class="score">1 point</span>`:   1,
+		`<span class="score">42 points</span>`: 42,
+		`<span class="age">2 hours ago</span>`: 0, // Job posting
+	}
+
+	for inner, want := range cases {
+		points, err := getPoints(parseSubtext(t, inner))
+		if err != nil {
+			t.Error("error: ", err)
+		} else if points != want {
+			t.Error("parsed ", points, " points instead of ", want, " from ", inner)
+		}
+	}
+}
+
+func TestGetNumComments(t *testing.T) {
+	cases := map[string]int{
+		`<a href="item?id=1">1&nbsp;comment</a>`:   1,
+		`<a href="item?id=1">37&nbsp;comments</a>`: 37,
+		`<a href="item?id=1">discuss</a>`:          0,
+		`<span class="age">2 hours ago</span>`:     0, // Job posting
+		`<a href="user?id=comment9" class="hnuser">comment9</a> | <a href="hide?id=1">hide</a> | ` +
+			`<a href="https://hn.algolia.com/?query=x">past</a> | <a href="flag?id=1">flag</a> | ` +
+			`<a href="item?id=1">5&nbsp;comments</a>`: 5,
+	}
+
+	for inner, want := range cases {
+		num, err := getNumComments(parseSubtext(t, inner))
+		if err != nil {
+			t.Error("error: ", err)
+		} else if num != want {
+			t.Error("parsed ", num, " comments instead of ", want, " from ", inner)
+		}
+	}
+}
+
+func TestGetTimePosted(t *testing.T) {
+	want := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	cases := []string{
+		`<span class="age" title="2021-10-16T12:00:00">3 hours ago</span>`,
+		`<span class="age" title="2021-10-16T12:00:00 1634385600">3 hours ago</span>`,
+	}
+
+	for _, inner := range cases {
+		posted, err := getTimePosted(parseSubtext(t, inner), time.UTC)
+		if err != nil {
+			t.Error("error: ", err)
+		} else if !posted.Equal(want) || posted.Location() != time.UTC {
+			t.Error("parsed ", posted, " instead of ", want, " from ", inner)
+		}
+	}
+}
+
+func TestGetRelativeTime(t *testing.T) {
+	anchor := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		`<span class="age"><a href="item?id=1">1 minute ago</a></span>`: anchor.Add(-time.Minute),
+		`<span class="age"><a href="item?id=1">3 hours ago</a></span>`:  anchor.Add(-3 * time.Hour),
+		`<span class="age"><a href="item?id=1">2 days ago</a></span>`:   anchor.Add(-48 * time.Hour),
+	}
+
+	for inner, want := range cases {
+		posted, err := getRelativeTime(parseSubtext(t, inner), anchor)
+		if err != nil {
+			t.Error("error: ", err)
+		} else if !posted.Equal(want) {
+			t.Error("parsed ", posted, " instead of ", want, " from ", inner)
+		}
+	}
+
+	_, err := getRelativeTime(parseSubtext(t, `<span class="age">yesterday</span>`), anchor)
+	if err == nil {
+		t.Error("accepted unrecognized relative age")
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	cases := map[string]string{
+		"Rust &amp; Go":             "Rust & Go",
+		"  Show HN:\tMy project \n": "Show HN: My project",
+		"Don&#8217;t panic":         "Don’t panic",
+		"“Quoted” titles stay":      "“Quoted” titles stay",
+	}
+
+	for raw, want := range cases {
+		if title := normalizeTitle(raw); title != want {
+			t.Errorf("normalized %q to %q instead of %q", raw, title, want)
+		}
+	}
+}
+
+func BenchmarkParsePage(b *testing.B) {
+	doc, err := htmlquery.LoadDoc("testdata/news.html")
+	if err != nil {
+		b.Fatal("error: ", err)
+	}
+	s := NewScraper()
+	retrieved := time.Now()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.parsePage(context.Background(), doc, 1, retrieved); err != nil {
+			b.Fatal("error: ", err)
+		}
+	}
+}
+
+func BenchmarkScrapeMultPages(b *testing.B) {
+	s := newTestScraper(b, serveFile("testdata/news.html"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ScrapeMultPages(1, 10); err != nil {
+			b.Fatal("error: ", err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+// Package hnscrapertest provides a fake HackerNews site for testing code built on hnscraper without network access.
+//
+//	site := hnscrapertest.NewServer(hnscrapertest.Story{ID: 1, Title: "Rust in production", Score: 120, By: "alice"})
+//	defer site.Close()
+//	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+//
+// The site serves its stories on the ranked listings, the newest listing, the past front page of the UTC day
+// they were posted on, and item pages, in the markup HackerNews uses, and can simulate throttling and markup changes.
+package hnscrapertest
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A Story is a post on the fake site.
+type Story struct {
+	ID       int       // The item ID, which must be unique and positive
+	Title    string    // The title
+	URL      string    // The link, empty for self posts, which link to their item page
+	By       string    // The submitter, empty for job ads, which also show no score or comments
+	Score    int       // The points
+	Posted   time.Time // When it was submitted, the zero time for an hour ago
+	Text     string    // The self text shown on the item page, as HTML
+	Comments []Comment // The discussion in page order, each comment's Depth nesting it under the one above
+}
+
+// A Comment is a comment on a Story.
+type Comment struct {
+	ID     int       // The item ID, which must be unique and positive
+	By     string    // The commenter
+	Text   string    // The text, as HTML
+	Posted time.Time // When it was submitted, the zero time for an hour ago
+	Depth  int       // How deeply it is nested, 0 for a direct reply to the story
+}
+
+// A Layout is a version of HackerNews' markup.
+type Layout int
+
+const (
+	LayoutClassic   Layout = iota // Title links directly in their cell with the "titlelink" class, which hnscraper parses
+	LayoutTitleline               // Title links wrapped in a "titleline" span, as HackerNews changed to in 2022
+)
+
+// The default for Server.SetPerPage
+const defaultPerPage = 30
+
+// A Server is a fake HackerNews site. Its methods are safe to call while it is serving.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	stories  []Story // In rank order
+	perPage  int     // How many stories a listing page holds
+	comments int     // How many comments an item page holds, 0 for all of them
+	layout   Layout  // The markup pages are rendered with
+	throttle int     // How many of the next requests are refused as throttled
+	requests int     // How many requests have been served
+}
+
+// NewServer starts a fake site holding the stories, ranked in the order given. Close it when done.
+func NewServer(stories ...Story) *Server {
+	s := &Server{perPage: defaultPerPage}
+	s.SetStories(stories...)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// Client returns a client that sends every request to the fake site whatever its host,
+// so scrapers given it with hnscraper.WithHTTPClient scrape the fake site instead of HackerNews.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.URL)
+	transport := s.Server.Client().Transport
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		req.Host = ""
+		return transport.RoundTrip(req)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// SetStories replaces the site's stories, ranked in the order given.
+func (s *Server) SetStories(stories ...Story) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stories = append([]Story(nil), stories...)
+}
+
+// SetPerPage sets how many stories each listing page holds, 30 by default.
+func (s *Server) SetPerPage(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.perPage = n
+}
+
+// SetCommentsPerPage splits discussions of more than n comments across item pages linked by "More",
+// as HackerNews does with long threads. 0, the default, shows every comment on one page.
+func (s *Server) SetCommentsPerPage(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.comments = n
+}
+
+// SetLayout switches the markup the site is rendered with, to test how code copes with HackerNews changing it.
+func (s *Server) SetLayout(layout Layout) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.layout = layout
+}
+
+// Throttle refuses the next n requests with a 503, as HackerNews does when it is polled too hard.
+func (s *Server) Throttle(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttle = n
+}
+
+// Requests returns how many requests the site has received, including throttled ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	throttled := s.throttle > 0
+	if throttled {
+		s.throttle--
+	}
+	stories := s.stories
+	perPage, layout, commentsPerPage := s.perPage, s.layout, s.comments
+	s.mu.Unlock()
+
+	if throttled {
+		http.Error(w, "Sorry.", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	var err error
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	switch r.URL.Path {
+	case "/", "/news":
+		pageNum := 1
+		if p := query.Get("p"); p != "" {
+			if pageNum, err = strconv.Atoi(p); err != nil || pageNum < 1 {
+				http.Error(w, "Bad page.", http.StatusBadRequest)
+				return
+			}
+		}
+		start := (pageNum - 1) * perPage
+		more := ""
+		if start+perPage < len(stories) {
+			more = "news?p=" + strconv.Itoa(pageNum+1)
+		}
+		err = renderListing(w, layout, window(stories, start, perPage), start+1, more)
+	case "/front":
+		day := query.Get("day")
+		var posted []Story
+		for _, story := range stories {
+			if postedAt(story.Posted).UTC().Format("2006-01-02") == day {
+				posted = append(posted, story)
+			}
+		}
+		pageNum := 1
+		if p := query.Get("p"); p != "" {
+			if pageNum, err = strconv.Atoi(p); err != nil || pageNum < 1 {
+				http.Error(w, "Bad page.", http.StatusBadRequest)
+				return
+			}
+		}
+		start := (pageNum - 1) * perPage
+		more := ""
+		if start+perPage < len(posted) {
+			more = fmt.Sprintf("front?day=%s&p=%d", day, pageNum+1)
+		}
+		err = renderListing(w, layout, window(posted, start, perPage), start+1, more)
+	case "/newest":
+		newest := append([]Story(nil), stories...)
+		sort.Slice(newest, func(i, j int) bool { return newest[i].ID > newest[j].ID })
+		start := 0
+		if next, _ := strconv.Atoi(query.Get("next")); next > 0 {
+			start = sort.Search(len(newest), func(i int) bool { return newest[i].ID <= next })
+		}
+		more := ""
+		if start+perPage < len(newest) {
+			more = fmt.Sprintf("newest?next=%d&n=%d", newest[start+perPage].ID, start+perPage+1)
+		}
+		err = renderListing(w, layout, window(newest, start, perPage), start+1, more)
+	case "/item":
+		id, _ := strconv.Atoi(query.Get("id"))
+		for _, story := range stories {
+			if story.ID == id {
+				comments, more := story.Comments, ""
+				if commentsPerPage > 0 {
+					pageNum, _ := strconv.Atoi(query.Get("p"))
+					if pageNum < 1 {
+						pageNum = 1
+					}
+					start, end := (pageNum-1)*commentsPerPage, pageNum*commentsPerPage
+					if start > len(comments) {
+						start = len(comments)
+					}
+					if end < len(comments) {
+						more = fmt.Sprintf("item?id=%d&p=%d", id, pageNum+1)
+					} else {
+						end = len(comments)
+					}
+					comments = comments[start:end]
+				}
+				if err := renderItem(w, layout, story, comments, more); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+		}
+		fmt.Fprint(w, "No such item.")
+	default:
+		http.NotFound(w, r)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// window returns up to n stories from start.
+func window(stories []Story, start, n int) []Story {
+	if start >= len(stories) {
+		return nil
+	}
+	if end := start + n; end < len(stories) {
+		return stories[start:end]
+	}
+
+	return stories[start:]
+}
+
+// row is a story ready to be rendered.
+type row struct {
+	Story
+	Rank      int
+	Link      string
+	Age       string // The age's title attribute, ie. "2021-10-16T11:30:00 1634383800"
+	Ago       string // The age as shown, ie. "30 minutes ago"
+	Titleline bool
+}
+
+func newRow(story Story, rank int, layout Layout) row {
+	r := row{Story: story, Rank: rank, Link: story.URL, Titleline: layout == LayoutTitleline}
+	if r.Link == "" {
+		r.Link = "item?id=" + strconv.Itoa(story.ID)
+	}
+	r.Age, r.Ago = age(story.Posted)
+
+	return r
+}
+
+// postedAt returns when something was posted, defaulting to an hour ago.
+func postedAt(posted time.Time) time.Time {
+	if posted.IsZero() {
+		return time.Now().Add(-time.Hour)
+	}
+
+	return posted
+}
+
+// age renders when something was posted the way HackerNews does.
+func age(posted time.Time) (string, string) {
+	now := time.Now()
+	posted = postedAt(posted)
+	title := posted.UTC().Format("2006-01-02T15:04:05") + " " + strconv.FormatInt(posted.Unix(), 10)
+
+	ago := now.Sub(posted)
+	amount, unit := int(ago/time.Minute), "minute"
+	switch {
+	case ago >= 24*time.Hour:
+		amount, unit = int(ago/(24*time.Hour)), "day"
+	case ago >= time.Hour:
+		amount, unit = int(ago/time.Hour), "hour"
+	}
+	if amount != 1 {
+		unit += "s"
+	}
+
+	return title, fmt.Sprintf("%d %s ago", amount, unit)
+}
+
+func renderListing(w http.ResponseWriter, layout Layout, stories []Story, firstRank int, more string) error {
+	rows := make([]row, len(stories))
+	for i, story := range stories {
+		rows[i] = newRow(story, firstRank+i, layout)
+	}
+
+	return listingTemplate.Execute(w, struct {
+		Rows []row
+		More string
+	}{rows, more})
+}
+
+// commentRow is a comment ready to be rendered.
+type commentRow struct {
+	Comment
+	Age, Ago string
+	Indent   int // The width of the indent image, 40 pixels a level
+}
+
+func renderItem(w http.ResponseWriter, layout Layout, story Story, comments []Comment, more string) error {
+	rows := make([]commentRow, len(comments))
+	for i, comment := range comments {
+		rows[i] = commentRow{Comment: comment, Indent: 40 * comment.Depth}
+		rows[i].Age, rows[i].Ago = age(comment.Posted)
+	}
+
+	return itemTemplate.Execute(w, struct {
+		Row      row
+		Comments []commentRow
+		More     string
+	}{newRow(story, 0, layout), rows, more})
+}
+
+var funcs = template.FuncMap{
+	// html passes text the story or comment gave as HTML through unescaped
+	"html": func(s string) template.HTML { return template.HTML(s) },
+	"comments": func(r row) string {
+		switch n := len(r.Comments); n {
+		case 0:
+			return "discuss"
+		case 1:
+			return "1 comment"
+		default:
+			return strconv.Itoa(n) + " comments"
+		}
+	},
+}
+
+// The templates follow HackerNews' markup, trimmed to what scrapers look at
+var templates = template.Must(template.New("header").Funcs(funcs).Parse(`<html lang="en"><head><title>Hacker News</title></head><body>` +
+	`<center><table id="hnmain"><tr><td><table><tr><td><span class="pagetop"><b class="hnname"><a href="news">Hacker News</a></b> ` +
+	`<a href="newest">new</a> | <a href="submit">submit</a></span></td><td><span class="pagetop"><a href="login?goto=news">login</a></span></td></tr></table></td></tr>` +
+	`<tr id="pagespace" style="height:10px"></tr>` +
+	`{{define "title"}}<td class="title">{{if .Titleline}}<span class="titleline"><a href="{{.Link}}">{{.Title}}</a></span>` +
+	`{{else}}<a href="{{.Link}}" class="titlelink">{{.Title}}</a>{{end}}</td>{{end}}` +
+	`{{define "subtext"}}<td class="subtext">{{if .By}}<span class="score" id="score_{{.ID}}">{{.Score}} point{{if ne .Score 1}}s{{end}}</span> ` +
+	`by <a href="user?id={{.By}}" class="hnuser">{{.By}}</a> {{end}}` +
+	`<span class="age" title="{{.Age}}"><a href="item?id={{.ID}}">{{.Ago}}</a></span> | <a href="hide?id={{.ID}}&amp;goto=news">hide</a>` +
+	`{{if .By}} | <a href="item?id={{.ID}}">{{comments .}}</a>{{end}}</td>{{end}}`))
+
+var listingTemplate = template.Must(template.Must(templates.Clone()).New("listing").Parse(`{{template "header"}}` +
+	`<tr><td><table class="itemlist">{{range .Rows}}` +
+	`<tr class="athing" id="{{.ID}}"><td class="title"><span class="rank">{{.Rank}}.</span></td>{{template "title" .}}</tr>` +
+	`<tr><td colspan="1"></td>{{template "subtext" .}}</tr><tr class="spacer" style="height:5px"></tr>{{end}}` +
+	`{{if .More}}<tr class="morespace"></tr><tr><td></td><td class="title"><a href="{{.More}}" class="morelink" rel="next">More</a></td></tr>{{end}}` +
+	`</table></td></tr></table></center></body></html>`))
+
+var itemTemplate = template.Must(template.Must(templates.Clone()).New("item").Parse(`{{template "header"}}` +
+	`<tr><td><table class="fatitem">` +
+	`<tr class="athing" id="{{.Row.ID}}"><td class="title"><span class="rank"></span></td>{{template "title" .Row}}</tr>` +
+	`<tr><td colspan="1"></td>{{template "subtext" .Row}}</tr>` +
+	`{{if .Row.Text}}<tr style="height:2px"></tr><tr><td colspan="1"></td><td>{{html .Row.Text}}</td></tr>{{end}}` +
+	`<tr style="height:10px"></tr><tr><td colspan="1"></td><td><form method="post" action="comment">` +
+	`<input type="hidden" name="parent" value="{{.Row.ID}}"><textarea name="text"></textarea></form></td></tr>` +
+	`</table><table class="comment-tree">{{range .Comments}}` +
+	`<tr class="athing comtr" id="{{.ID}}"><td><table><tr><td class="ind" indent="{{.Depth}}"><img src="s.gif" height="1" width="{{.Indent}}"></td>` +
+	`<td class="default"><div><span class="comhead"><a href="user?id={{.By}}" class="hnuser">{{.By}}</a> ` +
+	`<span class="age" title="{{.Age}}"><a href="item?id={{.ID}}">{{.Ago}}</a></span></span></div><br>` +
+	`<div class="comment"><span class="commtext c00">{{html .Text}}</span></div></td></tr></table></td></tr>{{end}}` +
+	`</table>{{if .More}}<table><tr><td class="title"><a href="{{.More}}" class="morelink" rel="next">More</a></td></tr></table>{{end}}` +
+	`</td></tr></table></center></body></html>`))
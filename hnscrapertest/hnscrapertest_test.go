@@ -0,0 +1,103 @@
+package hnscrapertest_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+)
+
+func stories(n int) []hnscrapertest.Story {
+	stories := make([]hnscrapertest.Story, n)
+	for i := range stories {
+		id := 100 + i
+		stories[i] = hnscrapertest.Story{ID: id, Title: "Story " + strconv.Itoa(id), URL: "https://example.com/" + strconv.Itoa(id),
+			By: "user" + strconv.Itoa(id), Score: 200 - i}
+	}
+
+	return stories
+}
+
+func TestListings(t *testing.T) {
+	site := hnscrapertest.NewServer(stories(45)...)
+	defer site.Close()
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+
+	pages, err := s.ScrapeMultPages(1, 2)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(pages[0].Posts) != 30 || len(pages[1].Posts) != 15 {
+		t.Fatal("scraped pages of ", len(pages[0].Posts), " and ", len(pages[1].Posts), " posts")
+	}
+	post := pages[1].Posts[0]
+	if post.ID != 130 || post.Rank != 31 || post.Title != "Story 130" || post.Score != 170 || post.By != "user130" ||
+		post.URL != "https://example.com/130" || post.TimeApprox || time.Since(post.TimePosted) > 2*time.Hour {
+		t.Errorf("scraped post incorrectly: %+v", post)
+	}
+
+	newest, err := s.IncrementalScrape(hnscraper.ListingNewest, 120)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(newest) != 24 || newest[0].ID != 144 {
+		t.Error("scraped ", len(newest), " new posts")
+	}
+}
+
+func TestItem(t *testing.T) {
+	posted := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	site := hnscrapertest.NewServer(hnscrapertest.Story{
+		ID: 1, Title: "Ask HN: Tabs or spaces?", By: "alice", Score: 5, Posted: posted, Text: "Asking for a friend.<p>Thanks!",
+		Comments: []hnscrapertest.Comment{
+			{ID: 2, By: "bob", Text: "Tabs.", Depth: 0},
+			{ID: 3, By: "carol", Text: "Spaces &amp; more.", Depth: 1},
+			{ID: 4, By: "dave", Text: "Neither.", Depth: 0},
+		},
+	})
+	defer site.Close()
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+
+	item, err := s.ScrapeItem(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if !item.Post.TimePosted.Equal(posted) || item.Post.NumComments != 3 || item.Text != "Asking for a friend.\n\nThanks!" {
+		t.Errorf("scraped item incorrectly: %+v", item)
+	}
+	if len(item.Comments) != 2 || len(item.Comments[0].Replies) != 1 || item.Comments[0].Replies[0].Text != "Spaces & more." {
+		t.Errorf("scraped comments incorrectly: %+v", item.Comments)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	site := hnscrapertest.NewServer(stories(3)...)
+	defer site.Close()
+	site.Throttle(1)
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+
+	if _, err := s.ScrapePage(1); !errors.Is(err, hnscraper.ErrThrottled) {
+		t.Error("returned ", err, " instead of ErrThrottled")
+	}
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Error("still throttled: ", err)
+	}
+	if site.Requests() != 2 {
+		t.Error("counted ", site.Requests(), " requests")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	site := hnscrapertest.NewServer(stories(3)...)
+	defer site.Close()
+	site.SetLayout(hnscrapertest.LayoutTitleline)
+	s := hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()))
+
+	var pageErr *hnscraper.PageError
+	if _, err := s.ScrapePage(1); !errors.As(err, &pageErr) || pageErr.Stage != "title" {
+		t.Error("returned ", err, " instead of a title PageError for changed markup")
+	}
+}
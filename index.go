@@ -0,0 +1,198 @@
+package hnscraper
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Index keeps scraped pages in memory and answers questions about the posts on them,
+// for analysis that doesn't warrant a database. Posts are identified by item ID.
+// An Index is safe for concurrent use.
+type Index struct {
+	mu        sync.RWMutex
+	latest    map[int]Post       // The most recently retrieved version of each post
+	retrieved map[int]time.Time  // When the latest version of each post was retrieved
+	firstSeen map[int]time.Time  // When each post was first retrieved
+	frontPage map[int]bool       // Which posts have appeared on the first page
+	positions map[int][]Position // Where each post was in every snapshot, oldest first
+}
+
+// A Position is where a post was listed in a single page snapshot.
+type Position struct {
+	Time time.Time // When the page was retrieved
+	Page int       // The number of the page the post was on
+	Rank int       // The post's rank, 0 if it was pinned
+}
+
+// PositionStats summarizes a post's position history.
+type PositionStats struct {
+	PeakRank      int           // The best rank the post reached, 0 if it was never ranked
+	PeakTime      time.Time     // When the post was first seen at its peak rank
+	FirstSeen     time.Time     // The earliest snapshot the post was in
+	LastSeen      time.Time     // The latest snapshot the post was in
+	FrontPageTime time.Duration // How long the post was on the front page, see Index.PositionStats
+	Snapshots     int           // How many snapshots the post was in
+}
+
+// NewIndex creates an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		latest:    make(map[int]Post),
+		retrieved: make(map[int]time.Time),
+		firstSeen: make(map[int]time.Time),
+		frontPage: make(map[int]bool),
+		positions: make(map[int][]Position),
+	}
+}
+
+// Ingest adds the pages to the index. Pages can be ingested in any order;
+// the index always keeps the most recently retrieved version of each post.
+func (ix *Index) Ingest(pages ...Page) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, page := range pages {
+		for _, post := range page.Posts {
+			if post.ID == 0 {
+				continue
+			}
+
+			if first, ok := ix.firstSeen[post.ID]; !ok || page.Retrieved.Before(first) {
+				ix.firstSeen[post.ID] = page.Retrieved
+			}
+			if last, ok := ix.retrieved[post.ID]; !ok || !page.Retrieved.Before(last) {
+				ix.latest[post.ID] = post
+				ix.retrieved[post.ID] = page.Retrieved
+			}
+			if page.Num == 1 {
+				ix.frontPage[post.ID] = true
+			}
+			ix.addPosition(post.ID, Position{Time: page.Retrieved, Page: page.Num, Rank: post.Rank})
+		}
+	}
+}
+
+// addPosition inserts the position into the post's history, keeping it in time order.
+// A snapshot that was already ingested is not added again.
+func (ix *Index) addPosition(id int, pos Position) {
+	history := ix.positions[id]
+	i := sort.Search(len(history), func(i int) bool {
+		return !history[i].Time.Before(pos.Time)
+	})
+	if i < len(history) && history[i] == pos {
+		return
+	}
+
+	history = append(history, Position{})
+	copy(history[i+1:], history[i:])
+	history[i] = pos
+	ix.positions[id] = history
+}
+
+// Len returns how many distinct posts the index holds.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.latest)
+}
+
+// Post returns the most recently retrieved version of the post.
+func (ix *Index) Post(id int) (Post, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	post, ok := ix.latest[id]
+	return post, ok
+}
+
+// TopByScore returns the posts submitted at or after since, from highest to lowest score.
+func (ix *Index) TopByScore(since time.Time) Posts {
+	return ix.query(func(p Post) bool {
+		return !p.TimePosted.Before(since)
+	})
+}
+
+// ByDomain returns the posts linking to the domain or one of its subdomains, from highest to lowest score.
+func (ix *Index) ByDomain(domain string) Posts {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+	return ix.query(func(p Post) bool {
+		postDomain := p.Domain()
+		return postDomain == domain || strings.HasSuffix(postDomain, "."+domain)
+	})
+}
+
+// FirstSeen returns when the post was first retrieved, and false if the index has never seen it.
+func (ix *Index) FirstSeen(id int) (time.Time, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	seen, ok := ix.firstSeen[id]
+	return seen, ok
+}
+
+// AppearedOnFrontPage reports whether the post was on the first page in any ingested snapshot.
+func (ix *Index) AppearedOnFrontPage(id int) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return ix.frontPage[id]
+}
+
+// PositionHistory returns where the post was in every ingested snapshot, oldest first.
+// Feed the index from a Monitor to follow posts as they move through the listings:
+//
+//	monitor.OnPage(func(page hnscraper.Page) { ix.Ingest(page) })
+func (ix *Index) PositionHistory(id int) []Position {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return append([]Position(nil), ix.positions[id]...)
+}
+
+// PositionStats summarizes the post's position history, and returns false if the index has never seen it.
+// FrontPageTime adds up the time between consecutive snapshots that both had the post on the first page,
+// so it is only as accurate as the snapshots are frequent.
+func (ix *Index) PositionStats(id int) (PositionStats, bool) {
+	history := ix.PositionHistory(id)
+	if len(history) == 0 {
+		return PositionStats{}, false
+	}
+
+	stats := PositionStats{
+		FirstSeen: history[0].Time,
+		LastSeen:  history[len(history)-1].Time,
+		Snapshots: len(history),
+	}
+	for i, pos := range history {
+		if pos.Rank > 0 && (stats.PeakRank == 0 || pos.Rank < stats.PeakRank) {
+			stats.PeakRank = pos.Rank
+			stats.PeakTime = pos.Time
+		}
+		if i > 0 && pos.Page == 1 && history[i-1].Page == 1 {
+			stats.FrontPageTime += pos.Time.Sub(history[i-1].Time)
+		}
+	}
+
+	return stats, true
+}
+
+// query returns the latest version of every post matching the predicate, from highest to lowest score.
+func (ix *Index) query(match func(Post) bool) Posts {
+	ix.mu.RLock()
+	var posts Posts
+	for _, post := range ix.latest {
+		if match(post) {
+			posts = append(posts, post)
+		}
+	}
+	ix.mu.RUnlock()
+
+	// Order by ID first so ties in score come out the same way every time
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+	return posts.SortByScore()
+}
@@ -0,0 +1,93 @@
+package hnscraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIndex(t *testing.T) {
+	early := time.Date(2021, 10, 16, 9, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	ix := NewIndex()
+	ix.Ingest(
+		Page{Num: 2, Retrieved: late, Posts: []Post{
+			{ID: 1, Score: 90, URL: "https://github.com/a", TimePosted: early.Add(-time.Hour)},
+			{ID: 3, Score: 5, URL: "https://example.com", TimePosted: early.Add(-48 * time.Hour)},
+		}},
+		Page{Num: 1, Retrieved: early, Posts: []Post{
+			{ID: 1, Score: 40, URL: "https://github.com/a", TimePosted: early.Add(-time.Hour)},
+			{ID: 2, Score: 60, URL: "https://gist.github.com/b", TimePosted: early.Add(-2 * time.Hour)},
+		}},
+	)
+
+	if ix.Len() != 3 {
+		t.Error("indexed ", ix.Len(), " posts instead of 3")
+	}
+	if post, _ := ix.Post(1); post.Score != 90 {
+		t.Error("kept score ", post.Score, " instead of the latest score of 90")
+	}
+	if seen, ok := ix.FirstSeen(1); !ok || !seen.Equal(early) {
+		t.Error("reported post first seen at ", seen)
+	}
+	if _, ok := ix.FirstSeen(4); ok {
+		t.Error("reported an unknown post as seen")
+	}
+	if !ix.AppearedOnFrontPage(1) || ix.AppearedOnFrontPage(3) {
+		t.Error("tracked front page appearances incorrectly")
+	}
+
+	top := ix.TopByScore(early.Add(-24 * time.Hour))
+	if len(top) != 2 || top[0].ID != 1 || top[1].ID != 2 {
+		t.Error("returned incorrect top posts: ", top)
+	}
+	if github := ix.ByDomain("github.com"); len(github) != 2 {
+		t.Error("returned ", len(github), " posts for github.com instead of 2")
+	}
+}
+
+func TestPositionHistory(t *testing.T) {
+	start := time.Date(2021, 10, 16, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	ix := NewIndex()
+	ix.Ingest(
+		Page{Num: 1, Retrieved: at(10), Posts: []Post{{ID: 2, Rank: 1}, {ID: 1, Rank: 2}}},
+		Page{Num: 2, Retrieved: at(0), Posts: []Post{{ID: 1, Rank: 35}}},
+		Page{Num: 1, Retrieved: at(5), Posts: []Post{{ID: 1, Rank: 4}}},
+		Page{Num: 1, Retrieved: at(15), Posts: []Post{{ID: 1, Rank: 2}}},
+		Page{Num: 2, Retrieved: at(20), Posts: []Post{{ID: 1, Rank: 31}}},
+	)
+	// Ingesting a snapshot twice doesn't duplicate it
+	ix.Ingest(Page{Num: 1, Retrieved: at(15), Posts: []Post{{ID: 1, Rank: 2}}})
+
+	history := ix.PositionHistory(1)
+	want := []Position{{at(0), 2, 35}, {at(5), 1, 4}, {at(10), 1, 2}, {at(15), 1, 2}, {at(20), 2, 31}}
+	if len(history) != len(want) {
+		t.Fatal("returned ", len(history), " positions instead of ", len(want))
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Error("returned position ", history[i], " instead of ", want[i])
+		}
+	}
+
+	stats, ok := ix.PositionStats(1)
+	if !ok {
+		t.Fatal("reported no stats for a tracked post")
+	}
+	if stats.PeakRank != 2 || !stats.PeakTime.Equal(at(10)) {
+		t.Error("reported peak rank ", stats.PeakRank, " at ", stats.PeakTime)
+	}
+	if stats.FrontPageTime != 10*time.Minute || stats.Snapshots != 5 {
+		t.Error("reported ", stats.FrontPageTime, " on the front page over ", stats.Snapshots, " snapshots")
+	}
+	if !stats.FirstSeen.Equal(at(0)) || !stats.LastSeen.Equal(at(20)) {
+		t.Error("reported the post seen from ", stats.FirstSeen, " to ", stats.LastSeen)
+	}
+	if _, ok := ix.PositionStats(3); ok {
+		t.Error("reported stats for an unknown post")
+	}
+}
@@ -0,0 +1,419 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// A Comment is a single comment in a HackerNews discussion, along with its replies.
+type Comment struct {
+	ID         int       // The HackerNews item ID of the comment
+	By         string    // The username of the commenter, empty if the comment was deleted
+	Text       string    // The text of the comment, with paragraphs separated by blank lines
+	TimePosted time.Time // Timestamp when the comment was submitted
+	Depth      int       // How deeply the comment is nested, 0 for a direct reply to the post
+	ParentID   int       // The item ID of the comment or post being replied to
+	StoryID    int       // The item ID of the post the discussion is under
+	StoryTitle string    // The title of the post the discussion is under
+	Replies    []Comment // The replies to the comment, in the order they appear on the page
+}
+
+// An Item is a post's own page, holding the post and the discussion under it.
+type Item struct {
+	Post      Post      // The post itself. Its Rank is always 0, as item pages don't rank posts
+	Text      string    // The text of self posts such as Ask HN, with paragraphs separated by blank lines
+	Comments  []Comment // The top-level comments, with replies nested under them
+	Retrieved time.Time // The time the request for the item was completed
+}
+
+// ItemStats summarizes the discussion under a post.
+type ItemStats struct {
+	Comments        int     // How many comments the item has, including deleted ones
+	Commenters      int     // How many different users commented
+	MaxDepth        int     // How deeply the deepest reply is nested, 0 if there are only top-level comments
+	AvgLength       float64 // The mean length of the comments' text in characters, leaving out deleted comments
+	CommentsPerHour float64 // Comments per hour between the post's submission and the item's retrieval
+}
+
+// Stats computes statistics about the item's comment tree.
+func (item Item) Stats() ItemStats {
+	var stats ItemStats
+	commenters := make(map[string]bool)
+	withText, length := 0, 0
+
+	var walk func(comments []Comment)
+	walk = func(comments []Comment) {
+		for _, comment := range comments {
+			stats.Comments++
+			if comment.Depth > stats.MaxDepth {
+				stats.MaxDepth = comment.Depth
+			}
+			if comment.By != "" {
+				commenters[comment.By] = true
+			}
+			if comment.Text != "" {
+				withText++
+				length += utf8.RuneCountInString(comment.Text)
+			}
+			walk(comment.Replies)
+		}
+	}
+	walk(item.Comments)
+
+	stats.Commenters = len(commenters)
+	if withText > 0 {
+		stats.AvgLength = float64(length) / float64(withText)
+	}
+	// Like Post.Velocity, avoid dividing by zero for items retrieved moments after submission
+	hours := item.Retrieved.Sub(item.Post.TimePosted).Hours()
+	if hours < 1.0/60 {
+		hours = 1.0 / 60
+	}
+	stats.CommentsPerHour = float64(stats.Comments) / hours
+
+	return stats
+}
+
+// ScrapeItem scrapes a post's page, including every comment on it.
+func ScrapeItem(id int) (Item, error) {
+	return defaultScraper.ScrapeItem(id)
+}
+
+// ScrapeItem scrapes a post's page, including every comment on it.
+func (s *Scraper) ScrapeItem(id int) (Item, error) {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	return s.scrapeItem(ctx, id)
+}
+
+// ScrapeItems scrapes many post pages concurrently, using the given number of workers.
+// See Scraper.ScrapeItems.
+func ScrapeItems(ctx context.Context, ids []int, workers int) ([]Item, error) {
+	return defaultScraper.ScrapeItems(ctx, ids, workers)
+}
+
+// ScrapeItems scrapes many post pages concurrently, using the given number of workers.
+// Requests are still spaced out by the Scraper's rate limit, however many workers there are.
+// The items are returned in the same order as the ids. A failed item is left as the zero Item
+// and doesn't stop the others; its error is reported in an *ItemsError.
+func (s *Scraper) ScrapeItems(ctx context.Context, ids []int, workers int) ([]Item, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if s.maxConcurrency > 0 && workers > s.maxConcurrency {
+		workers = s.maxConcurrency
+	}
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	items := make([]Item, len(ids))
+	errs := make([]error, len(ids))
+	forEach(ctx, len(ids), workers, 0, func(i int) {
+		items[i], errs[i] = s.scrapeItem(ctx, ids[i])
+	})
+
+	itemsErr := &ItemsError{Errs: map[int]error{}}
+	for i, err := range errs {
+		if err != nil {
+			itemsErr.Errs[ids[i]] = err
+		}
+	}
+	if len(itemsErr.Errs) > 0 {
+		return items, itemsErr
+	}
+
+	return items, nil
+}
+
+// An ItemsError reports which items of a batch could not be scraped.
+type ItemsError struct {
+	Errs map[int]error // Why each item failed, keyed by item ID
+}
+
+func (e *ItemsError) Error() string {
+	ids := make([]int, 0, len(e.Errs))
+	for id := range e.Errs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return fmt.Sprintf("could not scrape %d items, first item %d: %v", len(ids), ids[0], e.Errs[ids[0]])
+}
+
+func (s *Scraper) scrapeItem(ctx context.Context, id int) (Item, error) {
+	var item Item
+
+	if id < 1 {
+		return item, errors.New("item id must be a positive integer")
+	}
+
+	var more string
+	_, err := s.fetchPage(ctx, s.baseURL+"item?id="+strconv.Itoa(id), func(ctx context.Context, doc *html.Node) (err error) {
+		item, err = s.parseItem(ctx, doc, time.Now())
+		more = nextPage(doc)
+		return err
+	})
+	if err != nil || more == "" {
+		return item, err
+	}
+
+	// Long discussions are split across pages linked by "More", each carrying on the comment tree where the last stopped
+	flat := flattenComments(item.Comments)
+	seen := make(map[int]bool, len(flat))
+	for _, comment := range flat {
+		seen[comment.ID] = true
+	}
+	visited := map[string]bool{}
+	for more != "" && !visited[more] {
+		visited[more] = true
+		_, err := s.fetchPage(ctx, s.baseURL+more, func(ctx context.Context, doc *html.Node) error {
+			comments, err := s.getFlatComments(doc, item.Retrieved)
+			for _, comment := range comments {
+				if !seen[comment.ID] {
+					seen[comment.ID] = true
+					flat = append(flat, comment)
+				}
+			}
+			more = nextPage(doc)
+			return err
+		})
+		if err != nil {
+			return item, err
+		}
+	}
+	item.Comments, _ = nestComments(flat, 0)
+	linkComments(item.Comments, item.Post.ID, item.Post.ID, item.Post.Title)
+
+	return item, nil
+}
+
+// nextPage returns the link to the page continuing a listing or discussion, empty on the last page.
+func nextPage(doc *html.Node) string {
+	more := htmlquery.QuerySelector(doc, moreLinkExpr)
+	if more == nil {
+		return ""
+	}
+
+	return htmlquery.SelectAttr(more, "href")
+}
+
+// flattenComments lists the comment tree in page order, without the replies nested under each comment.
+func flattenComments(comments []Comment) []Comment {
+	var flat []Comment
+	for _, comment := range comments {
+		replies := comment.Replies
+		comment.Replies = nil
+		flat = append(flat, comment)
+		flat = append(flat, flattenComments(replies)...)
+	}
+
+	return flat
+}
+
+var (
+	itemRowsExpr    = xpath.MustCompile("//table[contains(@class, 'fatitem')]/tbody/tr")
+	selfTextExpr    = xpath.MustCompile("/td[2]")
+	formExpr        = xpath.MustCompile("//form")
+	commentRowsExpr = xpath.MustCompile("//tr[contains(@class, 'comtr')]")
+	commentHeadExpr = xpath.MustCompile("//span[contains(@class, 'comhead')]")
+	commentTextExpr = xpath.MustCompile("//*[contains(@class, 'commtext')]")
+	indentExpr      = xpath.MustCompile("//td[contains(@class, 'ind')]")
+	spacerExpr      = xpath.MustCompile("/img")
+)
+
+func (s *Scraper) parseItem(ctx context.Context, doc *html.Node, retrieved time.Time) (Item, error) {
+	var item Item
+
+	itemNodes := htmlquery.QuerySelectorAll(doc, itemRowsExpr)
+	if len(itemNodes) < 2 {
+		if isNoprocrast(doc) {
+			return item, ErrNoprocrast
+		}
+		return item, stageError("item", errors.New(errorMsg))
+	}
+	subtext := htmlquery.QuerySelector(itemNodes[1], subtextExpr)
+	if subtext == nil {
+		return item, stageError("subtext", errors.New(errorMsg))
+	}
+	post, err := s.getPost(ctx, itemNodes[0], subtext, retrieved)
+	if err != nil {
+		return item, err
+	}
+
+	// Self posts have their text in a row of its own, above the comment form
+	text := ""
+	for _, row := range itemNodes[2:] {
+		textNode := htmlquery.QuerySelector(row, selfTextExpr)
+		if textNode != nil && htmlquery.QuerySelector(textNode, formExpr) == nil {
+			text = nodeText(textNode)
+			break
+		}
+	}
+
+	comments, err := s.getComments(doc, retrieved)
+	if err != nil {
+		return item, err
+	}
+	linkComments(comments, post.ID, post.ID, post.Title)
+
+	item = Item{Post: post, Text: text, Comments: comments, Retrieved: retrieved}
+	return item, nil
+}
+
+// getComments parses the comment tree, nesting each comment under the closest shallower comment above it.
+func (s *Scraper) getComments(doc *html.Node, retrieved time.Time) ([]Comment, error) {
+	flat, err := s.getFlatComments(doc, retrieved)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, _ := nestComments(flat, 0)
+	return comments, nil
+}
+
+// getFlatComments parses the comments on the page in page order, without nesting them.
+func (s *Scraper) getFlatComments(doc *html.Node, retrieved time.Time) ([]Comment, error) {
+	var flat []Comment
+	for _, node := range htmlquery.QuerySelectorAll(doc, commentRowsExpr) {
+		comment, err := s.getComment(node, retrieved)
+		if err != nil {
+			return nil, stageError("comment", err)
+		}
+		flat = append(flat, comment)
+	}
+
+	return flat, nil
+}
+
+// nestComments builds the replies for comments at the given depth, returning how many flat comments were consumed.
+func nestComments(flat []Comment, depth int) ([]Comment, int) {
+	var comments []Comment
+	i := 0
+	for i < len(flat) && flat[i].Depth >= depth {
+		comment := flat[i]
+		i++
+		replies, n := nestComments(flat[i:], comment.Depth+1)
+		comment.Replies = replies
+		i += n
+		comments = append(comments, comment)
+	}
+
+	return comments, i
+}
+
+// linkComments fills in the parent and story of every comment in the tree.
+func linkComments(comments []Comment, parentID, storyID int, storyTitle string) {
+	for i := range comments {
+		comments[i].ParentID = parentID
+		comments[i].StoryID = storyID
+		comments[i].StoryTitle = storyTitle
+		linkComments(comments[i].Replies, comments[i].ID, storyID, storyTitle)
+	}
+}
+
+func (s *Scraper) getComment(node *html.Node, retrieved time.Time) (Comment, error) {
+	var comment Comment
+
+	id, err := getID(node)
+	if err != nil {
+		return comment, err
+	}
+
+	head := htmlquery.QuerySelector(node, commentHeadExpr)
+	if head == nil {
+		return comment, errors.New(errorMsg)
+	}
+	// Deleted comments have no author
+	author, err := getAuthor(head)
+	if err != nil {
+		return comment, err
+	}
+	posted, err := getTimePosted(head, s.location)
+	if err != nil {
+		posted, err = getRelativeTime(head, retrieved.In(s.location))
+		if err != nil {
+			return comment, err
+		}
+	}
+
+	text := ""
+	if textNode := htmlquery.QuerySelector(node, commentTextExpr); textNode != nil {
+		text = nodeText(textNode)
+	}
+
+	comment = Comment{
+		ID:         id,
+		By:         author,
+		Text:       text,
+		TimePosted: posted,
+		Depth:      getDepth(node),
+	}
+
+	return comment, nil
+}
+
+func getDepth(node *html.Node) int {
+	indent := htmlquery.QuerySelector(node, indentExpr)
+	if indent == nil {
+		return 0
+	}
+	if depth, err := strconv.Atoi(htmlquery.SelectAttr(indent, "indent")); err == nil {
+		return depth
+	}
+
+	// Older markup only indents with a spacer image, 40 pixels per level
+	spacer := htmlquery.QuerySelector(indent, spacerExpr)
+	if spacer == nil {
+		return 0
+	}
+	width, _ := strconv.Atoi(htmlquery.SelectAttr(spacer, "width"))
+	return width / 40
+}
+
+// nodeText converts HackerNews-formatted text to plain text, separating paragraphs with blank lines.
+// HN shortens long links with an ellipsis, so links are replaced with their full target.
+func nodeText(node *html.Node) string {
+	var paragraphs []string
+	var current strings.Builder
+
+	flush := func() {
+		if paragraph := strings.TrimSpace(current.String()); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+		current.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				current.WriteString(child.Data)
+			case child.Data == "p":
+				flush()
+				walk(child)
+			case child.Data == "a" && strings.HasSuffix(htmlquery.InnerText(child), "..."):
+				current.WriteString(htmlquery.SelectAttr(child, "href"))
+			case child.Data == "div" && htmlquery.SelectAttr(child, "class") == "reply":
+				// Skip the reply link
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,187 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+)
+
+func TestScrapeItem(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/item.html"), WithUTC())
+	item, err := s.ScrapeItem(28719320)
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+
+	if item.Post.ID != 28719320 || item.Post.Rank != 0 || item.Post.Score != 512 || item.Post.NumComments != 4 {
+		t.Errorf("parsed post incorrectly: %+v", item.Post)
+	}
+	wantText := "Please state the location and include REMOTE, INTERNS and/or VISA when that sort of candidate is welcome." +
+		"\n\nPlease only post if you are part of the hiring company & actively hiring."
+	if item.Text != wantText {
+		t.Errorf("parsed self text %q", item.Text)
+	}
+
+	if len(item.Comments) != 3 {
+		t.Error("parsed ", len(item.Comments), " top-level comments instead of 3")
+		return
+	}
+	first := item.Comments[0]
+	if first.ID != 28719401 || first.By != "acme_jobs" || first.Depth != 0 ||
+		!first.TimePosted.Equal(time.Unix(1633100531, 0)) {
+		t.Errorf("parsed first comment incorrectly: %+v", first)
+	}
+	if first.Text != "Acme Corp | Berlin, Germany | Senior Backend Engineer | ONSITE or REMOTE (EU)\n\n"+
+		"We build rockets & roller skates. Apply at https://acme.example.com/jobs/backend-engineer-position-2021" {
+		t.Errorf("parsed comment text %q", first.Text)
+	}
+	if len(first.Replies) != 1 || len(first.Replies[0].Replies) != 1 || first.Replies[0].Replies[0].Depth != 2 {
+		t.Error("did not nest replies")
+		return
+	}
+	if first.ParentID != item.Post.ID || first.Replies[0].Replies[0].ParentID != first.Replies[0].ID ||
+		first.Replies[0].Replies[0].StoryID != item.Post.ID {
+		t.Error("did not link replies to their parents")
+	}
+
+	deleted := item.Comments[2]
+	if deleted.By != "" || deleted.Text != "[deleted]" {
+		t.Errorf("parsed deleted comment incorrectly: %+v", deleted)
+	}
+}
+
+func TestScrapeItemPaged(t *testing.T) {
+	site := hnscrapertest.NewServer(hnscrapertest.Story{ID: 1, Title: "Ask HN: Who is hiring?", By: "whoishiring", Score: 100,
+		Comments: []hnscrapertest.Comment{
+			{ID: 10, By: "acme", Text: "Acme | Remote | Go"},
+			{ID: 11, By: "curious", Text: "Still hiring?", Depth: 1},
+			{ID: 12, By: "acme", Text: "Yes", Depth: 2},
+			{ID: 13, By: "globex", Text: "Globex | Berlin | Rust"},
+			{ID: 14, By: "initech", Text: "Initech | NYC | Java"},
+		}})
+	defer site.Close()
+	site.SetCommentsPerPage(2)
+	s := NewScraper(WithHTTPClient(site.Client()))
+
+	item, err := s.ScrapeItem(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(item.Comments) != 3 || item.Stats().Comments != 5 {
+		t.Fatal("scraped ", len(item.Comments), " top-level comments and ", item.Stats().Comments, " in all instead of 3 and 5")
+	}
+	// The reply on the second page stays under its parent on the first
+	reply := item.Comments[0].Replies[0]
+	if len(reply.Replies) != 1 || reply.Replies[0].ID != 12 || reply.Replies[0].ParentID != 11 || reply.Replies[0].StoryID != 1 {
+		t.Error("did not nest the reply continued on the next page: ", reply.Replies)
+	}
+	if site.Requests() != 3 {
+		t.Error("made ", site.Requests(), " requests instead of one per page")
+	}
+
+	entries, err := s.ScrapeHiring(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(entries) != 3 {
+		t.Error("parsed ", len(entries), " job entries across pages instead of 3")
+	}
+}
+
+func TestScrapeHiring(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/item.html"))
+	entries, err := s.ScrapeHiring(28719320)
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if len(entries) != 2 {
+		t.Error("parsed ", len(entries), " job entries instead of 2")
+		return
+	}
+
+	acme := entries[0]
+	if acme.Company != "Acme Corp" || acme.Location != "Berlin, Germany" || acme.Role != "Senior Backend Engineer" ||
+		!acme.Remote || !acme.Onsite || len(acme.Header) != 4 || acme.Details == "" {
+		t.Errorf("parsed job entry incorrectly: %+v", acme)
+	}
+
+	globex := entries[1]
+	if globex.Company != "Globex" || !globex.Remote || globex.Onsite {
+		t.Errorf("parsed job entry incorrectly: %+v", globex)
+	}
+}
+
+func TestScrapeItems(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "2" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/item.html")
+	}, WithRateLimit(20*time.Millisecond))
+
+	start := time.Now()
+	items, err := s.ScrapeItems(context.Background(), []int{1, 2, 3, 4}, 4)
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Error("scraped 4 items in ", elapsed, " despite a 20ms rate limit")
+	}
+
+	var itemsErr *ItemsError
+	if !errors.As(err, &itemsErr) || len(itemsErr.Errs) != 1 || itemsErr.Errs[2] == nil {
+		t.Error("returned ", err, " instead of an error for item 2")
+	}
+	if len(items) != 4 || items[0].Post.ID == 0 || items[1].Post.ID != 0 || items[3].Post.ID == 0 {
+		t.Error("did not return items in order with the failed item left empty")
+	}
+}
+
+func BenchmarkParseItem(b *testing.B) {
+	doc, err := htmlquery.LoadDoc("testdata/item.html")
+	if err != nil {
+		b.Fatal("error: ", err)
+	}
+	s := NewScraper()
+	retrieved := time.Now()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.parseItem(context.Background(), doc, retrieved); err != nil {
+			b.Fatal("error: ", err)
+		}
+	}
+}
+
+func TestItemStats(t *testing.T) {
+	posted := time.Date(2021, 10, 16, 9, 0, 0, 0, time.UTC)
+	item := Item{
+		Post:      Post{ID: 1, TimePosted: posted},
+		Retrieved: posted.Add(2 * time.Hour),
+		Comments: []Comment{
+			{ID: 2, By: "alice", Text: "First!", Replies: []Comment{
+				{ID: 3, By: "bob", Text: "Second", Depth: 1, Replies: []Comment{
+					{ID: 4, By: "alice", Text: "Thïrd", Depth: 2},
+				}},
+				{ID: 5, Depth: 1},
+			}},
+		},
+	}
+
+	stats := item.Stats()
+	want := ItemStats{Comments: 4, Commenters: 2, MaxDepth: 2, AvgLength: 17.0 / 3, CommentsPerHour: 2}
+	if stats != want {
+		t.Errorf("computed stats %+v instead of %+v", stats, want)
+	}
+	if (Item{}).Stats() != (ItemStats{}) {
+		t.Error("computed stats for an item without comments")
+	}
+}
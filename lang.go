@@ -0,0 +1,117 @@
+package hnscraper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scriptLangs maps scripts that are (almost) only used by one language to its ISO 639-1 code.
+// Han, Hiragana, and Katakana are handled separately, as Japanese mixes them.
+var scriptLangs = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are short, frequent words that tell Latin-script languages apart.
+// Words common to several of the languages are left out.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "for", "with", "on", "how", "why", "what", "your", "you", "from", "are", "this", "that", "it", "my", "i", "an", "a"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "für", "auf", "wie", "warum", "ich", "zu", "den", "von", "sie", "wir"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "pour", "dans", "pas", "avec", "sur", "qui", "du", "au", "je", "ce", "nous"},
+	"es": {"el", "los", "las", "y", "es", "por", "con", "para", "del", "que", "cómo", "qué", "se", "una", "su"},
+	"pt": {"os", "um", "não", "é", "com", "do", "da", "em", "como", "uma", "no", "na"},
+	"it": {"il", "gli", "è", "per", "non", "che", "della", "di", "sono", "lo", "un"},
+	"nl": {"het", "een", "niet", "van", "met", "voor", "op", "hoe", "waarom", "ik", "zijn", "wij"},
+}
+
+// stopwordLangs indexes stopwords by word.
+var stopwordLangs = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+
+	return index
+}()
+
+// DetectLanguage guesses the language of a short text such as a post title, returning its ISO 639-1 code, ie. "en",
+// or an empty string if the text has no letters. Languages with their own script are recognised by it; Latin-script
+// text is told apart by common words, and is taken to be English unless something points to another language,
+// as most HackerNews titles are. Short titles can be misjudged, so treat the result as a hint.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	han, kana, latin, letters := 0, 0, 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, sl := range scriptLangs {
+				if unicode.Is(sl.script, r) {
+					counts[sl.lang]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese text always has some kana among its kanji
+	if kana > 0 {
+		counts["ja"] += kana + han
+	} else if han > 0 {
+		counts["zh"] += han
+	}
+	best, bestCount := "", 0
+	for lang, count := range counts {
+		if count > bestCount || (count == bestCount && lang < best) {
+			best, bestCount = lang, count
+		}
+	}
+	if bestCount > latin {
+		return best
+	}
+
+	return detectLatin(text)
+}
+
+// detectLatin picks the Latin-script language with the most stopwords in the text, preferring English on a tie.
+func detectLatin(text string) string {
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, lang := range stopwordLangs[word] {
+			scores[lang]++
+		}
+	}
+
+	best := "en"
+	for lang, score := range scores {
+		if score > scores[best] || (score == scores[best] && best != "en" && lang < best) {
+			best = lang
+		}
+	}
+
+	return best
+}
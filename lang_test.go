@@ -0,0 +1,25 @@
+package hnscraper
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Show HN: A scraper for HackerNews":         "en",
+		"Rust 2.0":                                  "en",
+		"Warum ich nicht mehr mit Java arbeite":     "de",
+		"Pourquoi nous avons quitté le cloud":       "fr",
+		"Cómo escribir un compilador en una semana": "es",
+		"Come scrivere un compilatore per il web":   "it",
+		"Hoe wij onze database hebben gemigreerd":   "nl",
+		"Как мы переписали бэкенд на Go":            "ru",
+		"東京の新しいスタートアップ":                             "ja",
+		"如何用Go编写编译器":                                "zh",
+		"개발자를 위한 Rust 입문":                           "ko",
+		"2021":                                      "",
+	}
+	for text, want := range cases {
+		if lang := DetectLanguage(text); lang != want {
+			t.Errorf("detected %q as %q instead of %q", text, lang, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// A Leader is a user listed on the HackerNews leaderboard of users with the most karma.
+type Leader struct {
+	Rank     int    // The user's position on the leaderboard
+	Username string // The user's username
+	Karma    int    // The user's karma
+}
+
+var (
+	leaderRowsExpr = xpath.MustCompile("//tr[contains(@class, 'athing')]")
+	leaderCellExpr = xpath.MustCompile("/td")
+	leaderUserExpr = xpath.MustCompile("//a[contains(@class, 'hnuser')]")
+)
+
+// ScrapeLeaders scrapes the leaderboard of users with the most karma.
+func ScrapeLeaders() ([]Leader, error) {
+	return defaultScraper.ScrapeLeaders()
+}
+
+// ScrapeLeaders scrapes the leaderboard of users with the most karma.
+func (s *Scraper) ScrapeLeaders() ([]Leader, error) {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	var leaders []Leader
+	_, err := s.fetchPage(ctx, s.baseURL+"leaders", func(ctx context.Context, doc *html.Node) error {
+		for _, row := range htmlquery.QuerySelectorAll(doc, leaderRowsExpr) {
+			leader, err := getLeader(row)
+			if err != nil {
+				return stageError("leader", err)
+			}
+			leaders = append(leaders, leader)
+		}
+		return nil
+	})
+
+	return leaders, err
+}
+
+func getLeader(row *html.Node) (Leader, error) {
+	var leader Leader
+
+	cells := htmlquery.QuerySelectorAll(row, leaderCellExpr)
+	if len(cells) < 3 {
+		return leader, errors.New(errorMsg)
+	}
+
+	rank, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(htmlquery.InnerText(cells[0])), "."))
+	if err != nil {
+		return leader, errors.New(errorMsg)
+	}
+	user := htmlquery.QuerySelector(row, leaderUserExpr)
+	if user == nil {
+		return leader, errors.New(errorMsg)
+	}
+	// The karma is padded with non-breaking spaces, which strings.TrimSpace also trims
+	karma, err := strconv.Atoi(strings.TrimSpace(htmlquery.InnerText(cells[len(cells)-1])))
+	if err != nil {
+		return leader, errors.New(errorMsg)
+	}
+
+	leader = Leader{Rank: rank, Username: htmlquery.InnerText(user), Karma: karma}
+	return leader, nil
+}
@@ -0,0 +1,36 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestScrapeLeaders(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/leaders.html"))
+	leaders, err := s.ScrapeLeaders()
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if len(leaders) != 3 {
+		t.Error("returned ", len(leaders), " leaders instead of 3")
+		return
+	}
+	if leaders[1] != (Leader{Rank: 2, Username: "tptacek", Karma: 361223}) {
+		t.Errorf("parsed leader incorrectly: %+v", leaders[1])
+	}
+}
+
+func TestScrapeLeadersPageError(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><table><tr class="athing"><td>1.</td><td>no user</td></tr></table></body></html>`))
+	})
+
+	var pageErr *PageError
+	if _, err := s.ScrapeLeaders(); !errors.As(err, &pageErr) || pageErr.Stage != "leader" {
+		t.Error("returned ", err, " instead of a PageError")
+	}
+}
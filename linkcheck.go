@@ -0,0 +1,98 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// A LinkStatus is the result of checking whether the link a post points at still works.
+type LinkStatus struct {
+	Post       Post   // The post that was checked
+	StatusCode int    // The final HTTP status code, 0 if there was no response
+	FinalURL   string // The URL reached after following redirects
+	Err        error  // Why the link could not be checked, ie. a DNS failure or timeout
+}
+
+// Dead reports whether the link failed outright or responded with an error status.
+func (l LinkStatus) Dead() bool {
+	return l.Err != nil || l.StatusCode >= 400
+}
+
+// A LinkChecker checks whether the links posts point at are still alive.
+// The zero value is ready to use with conservative defaults.
+type LinkChecker struct {
+	Client      *http.Client  // The client to check links with, nil for a default client
+	Timeout     time.Duration // How long each link may take, 0 for 10 seconds
+	Concurrency int           // How many links are checked at once, 0 for 4
+	Interval    time.Duration // The minimum time between starting checks, 0 for no limit
+}
+
+// Check checks the link of every post, returning the results in the same order as the posts.
+// Posts without an external link, such as Ask HN, are not requested and are never dead.
+func (c *LinkChecker) Check(ctx context.Context, posts []Post) []LinkStatus {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = 4
+	}
+
+	results := make([]LinkStatus, len(posts))
+	var external []int
+	for i, post := range posts {
+		results[i] = LinkStatus{Post: post}
+		if post.Domain() != "" {
+			external = append(external, i)
+		}
+	}
+
+	forEach(ctx, len(external), concurrency, c.Interval, func(i int) {
+		post := posts[external[i]]
+		results[external[i]] = checkLink(ctx, client, timeout, post)
+	})
+
+	return results
+}
+
+func checkLink(ctx context.Context, client *http.Client, timeout time.Duration, post Post) LinkStatus {
+	status := LinkStatus{Post: post}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := requestLink(ctx, client, http.MethodHead, post.URL)
+	// Plenty of servers don't implement HEAD, so retry those with GET
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = requestLink(ctx, client, http.MethodGet, post.URL)
+	}
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.StatusCode = resp.StatusCode
+	status.FinalURL = resp.Request.URL.String()
+	return status
+}
+
+func requestLink(ctx context.Context, client *http.Client, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only the status matters, so don't download the body
+	resp.Body.Close()
+
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLinkChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/alive", http.StatusMovedPermanently)
+		case "/alive":
+			w.WriteHeader(http.StatusOK)
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	posts := []Post{
+		{ID: 1, URL: server.URL + "/moved"},
+		{ID: 2, URL: server.URL + "/gone"},
+		{ID: 3, URL: server.URL + "/nohead"},
+		{ID: 4, URL: "item?id=4"},
+		{ID: 5, URL: "http://127.0.0.1:1/unreachable"},
+	}
+	results := (&LinkChecker{Concurrency: 2}).Check(context.Background(), posts)
+
+	if results[0].StatusCode != http.StatusOK || results[0].FinalURL != server.URL+"/alive" {
+		t.Error("did not follow redirect: ", results[0])
+	}
+	if !results[1].Dead() || results[1].StatusCode != http.StatusNotFound {
+		t.Error("did not report missing page as dead: ", results[1])
+	}
+	if results[2].Dead() {
+		t.Error("did not fall back to GET: ", results[2])
+	}
+	if results[3].Dead() || results[3].StatusCode != 0 {
+		t.Error("checked a post without an external link: ", results[3])
+	}
+	if !results[4].Dead() || results[4].Err == nil {
+		t.Error("did not report unreachable host as dead: ", results[4])
+	}
+	for i, result := range results {
+		if result.Post.ID != posts[i].ID {
+			t.Error("returned results out of order")
+		}
+	}
+}
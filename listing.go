@@ -0,0 +1,83 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// A Listing is one of HackerNews' lists of posts, named by its path.
+type Listing string
+
+// The listings IncrementalScrape can walk.
+const (
+	ListingNews    Listing = "news"    // The front page, ranked
+	ListingNewest  Listing = "newest"  // Every new submission, newest first
+	ListingAsk     Listing = "ask"     // Ask HN posts, ranked
+	ListingShow    Listing = "show"    // Show HN posts, ranked
+	ListingShowNew Listing = "shownew" // Show HN posts, newest first
+	ListingJobs    Listing = "jobs"    // Job postings, newest first
+	ListingHidden  Listing = "hidden"  // The posts the logged-in account has hidden, see WithSession
+)
+
+// maxIncrementalPages bounds how far IncrementalScrape walks back, in case the last seen post has been deleted
+const maxIncrementalPages = 10
+
+var moreLinkExpr = xpath.MustCompile("//a[contains(@class, 'morelink')]")
+
+// IncrementalScrape returns the posts on a listing that are newer than lastSeenID. See Scraper.IncrementalScrape.
+func IncrementalScrape(listing Listing, lastSeenID int) ([]Post, error) {
+	return defaultScraper.IncrementalScrape(listing, lastSeenID)
+}
+
+// IncrementalScrape returns the posts on a listing that are newer than lastSeenID, in listing order,
+// following the listing's "More" link only until it reaches a post that isn't newer, so polling a busy listing
+// costs a single request most of the time. Pass the ID of the first post of the previous call as the next lastSeenID.
+// A lastSeenID of 0 returns just the first page.
+//
+// It is meant for listings ordered newest first, such as ListingNewest; on ranked listings it stops at the first
+// older post. At most 10 pages are walked, in case the last seen post was deleted.
+func (s *Scraper) IncrementalScrape(listing Listing, lastSeenID int) ([]Post, error) {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	var posts []Post
+	next := string(listing)
+	for pageNum := 1; pageNum <= maxIncrementalPages; pageNum++ {
+		var page Page
+		var more string
+		_, err := s.fetchPage(ctx, s.baseURL+next, func(ctx context.Context, doc *html.Node) (err error) {
+			if page, err = s.parsePage(ctx, doc, pageNum, time.Now()); err != nil {
+				return err
+			}
+			if lastSeenID == 0 {
+				return nil
+			}
+			// The link is relative to the site's root
+			if more = nextPage(doc); strings.Contains(more, "//") {
+				return stageError("more link", errors.New(errorMsg))
+			}
+			return nil
+		})
+		if err != nil {
+			return posts, err
+		}
+
+		for _, post := range page.Posts {
+			if post.ID <= lastSeenID {
+				return posts, nil
+			}
+			posts = append(posts, post)
+		}
+		if lastSeenID == 0 || more == "" {
+			return posts, nil
+		}
+		next = more
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,109 @@
+package hnscraper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+)
+
+// listingPage renders a listing holding posts with the IDs, ranked from the first rank, linking to next if it isn't empty.
+func listingPage(ids []int, firstRank int, next string) string {
+	var b strings.Builder
+	b.WriteString(`<html><body><table class="itemlist">`)
+	for i, id := range ids {
+		fmt.Fprintf(&b, `<tr class='athing' id='%d'><td class="title"><span class="rank">%d.</span></td><td class="title">`+
+			`<a href="https://example.com/%d" class="titlelink">Post %d</a></td></tr><tr><td class="subtext">`+
+			`<span class="score">1 point</span> by <a href="user?id=u%d" class="hnuser">u%d</a> `+
+			`<span class="age" title="2021-10-16T11:30:00"><a href="item?id=%d">30 minutes ago</a></span> | `+
+			`<a href="item?id=%d">discuss</a></td></tr><tr class="spacer"></tr>`,
+			id, firstRank+i, id, id, id, id, id, id)
+	}
+	if next != "" {
+		fmt.Fprintf(&b, `<tr class="morespace"></tr><tr><td class="title"><a href="%s" class="morelink" rel="next">More</a></td></tr>`, next)
+	}
+	b.WriteString(`</table></body></html>`)
+
+	return b.String()
+}
+
+func TestIncrementalScrape(t *testing.T) {
+	var requests int32
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.String() {
+		case "/newest":
+			fmt.Fprint(w, listingPage([]int{110, 109, 108}, 1, "newest?next=107&n=4"))
+		case "/newest?next=107&n=4":
+			fmt.Fprint(w, listingPage([]int{107, 106, 105}, 4, "newest?next=104&n=7"))
+		case "/ask":
+			fmt.Fprint(w, `<html><body>Nothing here</body></html>`)
+		default:
+			t.Error("requested ", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+
+	posts, err := s.IncrementalScrape(ListingNewest, 106)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(posts) != 4 || posts[0].ID != 110 || posts[3].ID != 107 || posts[3].Rank != 4 {
+		t.Error("returned incorrect posts: ", posts)
+	}
+
+	// Nothing new only costs a single request
+	atomic.StoreInt32(&requests, 0)
+	if posts, err := s.IncrementalScrape(ListingNewest, 110); err != nil || len(posts) != 0 {
+		t.Error("returned ", posts, err, " when nothing was new")
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Error("made ", n, " requests when the first post was already seen")
+	}
+
+	if posts, err := s.IncrementalScrape(ListingNewest, 0); err != nil || len(posts) != 3 {
+		t.Error("returned ", len(posts), " posts instead of the first page: ", err)
+	}
+
+	// Parse failures are reported like any other scrape's
+	var pageErr *PageError
+	if _, err := s.IncrementalScrape(ListingAsk, 0); !errors.As(err, &pageErr) || pageErr.Stage != "listing" || pageErr.Status != http.StatusOK {
+		t.Error("returned ", err, " instead of a PageError")
+	}
+}
+
+func TestScrapeFrontPageTop(t *testing.T) {
+	stories := make([]hnscrapertest.Story, 9)
+	for i := range stories {
+		stories[i] = hnscrapertest.Story{ID: i + 1, Title: "Post", By: "alice", Score: 10}
+	}
+	site := hnscrapertest.NewServer(stories...)
+	defer site.Close()
+	site.SetPerPage(3)
+	s := NewScraper(WithHTTPClient(site.Client()))
+
+	posts, err := s.ScrapeFrontPageTop(5)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(posts) != 5 || posts[4].ID != 5 || site.Requests() != 2 {
+		t.Error("scraped ", len(posts), " posts in ", site.Requests(), " requests instead of 5 in 2")
+	}
+
+	if posts, err := s.ScrapeFrontPageTop(100); err != nil || len(posts) != 9 {
+		t.Error("scraped ", len(posts), " posts instead of stopping at the end of the listing: ", err)
+	}
+
+	posts, err = s.ScrapeUntil(func(post Post) bool { return post.ID <= 7 })
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(posts) != 7 || posts[6].ID != 7 {
+		t.Error("scraped ", len(posts), " posts instead of the 7 matching ones")
+	}
+}
@@ -0,0 +1,201 @@
+package hnscraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Monitor scrapes a range of listing pages on an interval, handing every snapshot to its handlers.
+// Create one with NewMonitor, register handlers, then Start it.
+type Monitor struct {
+	scraper   *Scraper
+	startPage int
+	endPage   int
+	interval  time.Duration
+	onPage    []func(Page)
+	onError   []func(error)
+	cancel    context.CancelFunc // Cancels the in-flight scrape
+	stop      chan struct{}      // Closed to stop polling
+	done      chan struct{}      // Closed once polling has stopped and the handlers have returned
+	startOnce sync.Once
+	closeOnce sync.Once
+
+	onChange []func(previous, current Post) // Compare every post to its version from the previous poll
+	last     map[string]Post                // The latest version of the posts seen, by Key, only touched by the polling goroutine
+}
+
+// NewMonitor creates a Monitor that scrapes pages startPage to endPage with the scraper every interval.
+// A nil scraper uses the default options.
+func NewMonitor(s *Scraper, startPage, endPage int, interval time.Duration) *Monitor {
+	if s == nil {
+		s = defaultScraper
+	}
+
+	return &Monitor{
+		scraper:   s,
+		startPage: startPage,
+		endPage:   endPage,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// OnPage calls fn with every page scraped, in page order. It must be called before Start.
+// If the scraper has a stale cache, pages served while HackerNews is down arrive here with Page.Stale set,
+// rather than as errors.
+func (m *Monitor) OnPage(fn func(Page)) {
+	m.onPage = append(m.onPage, fn)
+}
+
+// OnError calls fn with every failed scrape. Polling carries on at the next interval. It must be called before Start.
+func (m *Monitor) OnError(fn func(error)) {
+	m.onError = append(m.onError, fn)
+}
+
+// OnScoreChange calls fn with every post whose score changed since the previous poll, and its old and new score.
+// Posts are compared across pages, so a post moving to another page still counts. It must be called before Start.
+func (m *Monitor) OnScoreChange(fn func(post Post, old, new int)) {
+	m.onChange = append(m.onChange, func(previous, current Post) {
+		if previous.Score != current.Score {
+			fn(current, previous.Score, current.Score)
+		}
+	})
+}
+
+// OnCommentCountChange calls fn with every post whose number of comments changed since the previous poll,
+// and its old and new count. It must be called before Start.
+func (m *Monitor) OnCommentCountChange(fn func(post Post, old, new int)) {
+	m.onChange = append(m.onChange, func(previous, current Post) {
+		if previous.NumComments != current.NumComments {
+			fn(current, previous.NumComments, current.NumComments)
+		}
+	})
+}
+
+// OnTitleChange calls fn with every post whose title was edited since the previous poll, and its old and new title.
+// It must be called before Start.
+func (m *Monitor) OnTitleChange(fn func(post Post, old, new string)) {
+	m.onChange = append(m.onChange, func(previous, current Post) {
+		if previous.Title != current.Title {
+			fn(current, previous.Title, current.Title)
+		}
+	})
+}
+
+// Start scrapes immediately and then every interval in the background until the Monitor is closed.
+func (m *Monitor) Start() {
+	m.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.cancel = cancel
+		go m.run(ctx)
+	})
+}
+
+func (m *Monitor) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.poll(ctx)
+
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll scrapes every page once, stopping early if the Monitor is closed.
+func (m *Monitor) poll(ctx context.Context) {
+	seen := make(map[string]Post)
+	// A poll that stops early only updates the posts it reached, keeping the rest for the next poll
+	defer func() {
+		if seen == nil {
+			return
+		} else if m.last == nil {
+			m.last = seen
+			return
+		}
+		for key, post := range seen {
+			m.last[key] = post
+		}
+	}()
+
+	for i := m.startPage; i <= m.endPage; i++ {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+
+		page, err := m.scraper.scrapePage(ctx, i)
+		if err != nil {
+			for _, fn := range m.onError {
+				fn(err)
+			}
+			return
+		}
+		for _, fn := range m.onPage {
+			fn(page)
+		}
+		// Stale pages are an earlier poll's snapshot, so they hold no changes
+		if !page.Stale {
+			m.compare(page, seen)
+		}
+	}
+
+	// Posts that fell out of the range are forgotten once every page has been polled
+	m.last, seen = seen, nil
+}
+
+// compare hands every post on the page that was seen on the previous poll to the change handlers,
+// recording it in seen for the next poll.
+func (m *Monitor) compare(page Page, seen map[string]Post) {
+	for _, post := range page.Posts {
+		key := post.Key()
+		// Posts that moved to a later page while it was polled show up twice
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if previous, ok := m.last[key]; ok {
+			for _, fn := range m.onChange {
+				fn(previous, post)
+			}
+		}
+		seen[key] = post
+	}
+}
+
+// Close stops polling and waits for the scrape in progress, and the handlers it calls, to finish.
+func (m *Monitor) Close() error {
+	return m.Shutdown(context.Background())
+}
+
+// Shutdown stops polling and waits for the scrape in progress, and the handlers it calls, to finish.
+// If ctx is done first, the scrape in progress is cancelled and ctx's error is returned
+// once the handlers have returned.
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+	})
+	// Also keeps a Monitor that was never started from starting later
+	m.startOnce.Do(func() {})
+	if m.cancel == nil {
+		return nil
+	}
+	defer m.cancel()
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-m.done
+		return ctx.Err()
+	}
+}
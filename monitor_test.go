@@ -0,0 +1,141 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+)
+
+func TestMonitor(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/news.html"))
+	m := NewMonitor(s, 1, 2, 20*time.Millisecond)
+
+	var mu sync.Mutex
+	var nums []int
+	m.OnPage(func(page Page) {
+		mu.Lock()
+		defer mu.Unlock()
+		nums = append(nums, page.Num)
+	})
+	m.Start()
+
+	time.Sleep(70 * time.Millisecond)
+	if err := m.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	mu.Lock()
+	polled := len(nums)
+	mu.Unlock()
+	if polled < 4 || nums[0] != 1 || nums[1] != 2 {
+		t.Fatal("scraped pages ", nums)
+	}
+
+	// Nothing runs after Close returns
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(nums) != polled {
+		t.Error("kept polling after Close")
+	}
+}
+
+func TestMonitorShutdownTimeout(t *testing.T) {
+	arrived := make(chan struct{})
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-r.Context().Done()
+	})
+	m := NewMonitor(s, 1, 1, time.Minute)
+	var errs int32
+	m.OnError(func(err error) { atomic.AddInt32(&errs, 1) })
+	m.Start()
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("returned ", err, " instead of the shutdown deadline")
+	}
+	if atomic.LoadInt32(&errs) != 1 {
+		t.Error("did not report the cancelled scrape")
+	}
+}
+
+func TestMonitorCloseUnstarted(t *testing.T) {
+	m := NewMonitor(nil, 1, 1, time.Minute)
+	if err := m.Close(); err != nil {
+		t.Error("error: ", err)
+	}
+	m.Start()
+	if err := m.Close(); err != nil {
+		t.Error("error: ", err)
+	}
+}
+
+func TestMonitorFieldChanges(t *testing.T) {
+	posted := time.Now().Add(-time.Hour)
+	site := hnscrapertest.NewServer(
+		hnscrapertest.Story{ID: 1, Title: "Rust in production", By: "alice", Score: 10, Posted: posted},
+		hnscrapertest.Story{ID: 2, Title: "Go generics", By: "bob", Score: 5, Posted: posted},
+	)
+	defer site.Close()
+	m := NewMonitor(NewScraper(WithHTTPClient(site.Client())), 1, 1, 20*time.Millisecond)
+
+	type change struct {
+		id       int
+		old, new interface{}
+	}
+	polled := make(chan struct{}, 100)
+	var mu sync.Mutex
+	var scores, counts, titles []change
+	m.OnPage(func(Page) { polled <- struct{}{} })
+	m.OnScoreChange(func(post Post, old, new int) {
+		mu.Lock()
+		defer mu.Unlock()
+		scores = append(scores, change{post.ID, old, new})
+	})
+	m.OnCommentCountChange(func(post Post, old, new int) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts = append(counts, change{post.ID, old, new})
+	})
+	m.OnTitleChange(func(post Post, old, new string) {
+		mu.Lock()
+		defer mu.Unlock()
+		titles = append(titles, change{post.ID, old, new})
+	})
+	m.Start()
+	<-polled
+
+	site.SetStories(
+		hnscrapertest.Story{ID: 1, Title: "Rust in production (2021)", By: "alice", Score: 25, Posted: posted,
+			Comments: []hnscrapertest.Comment{{ID: 10, By: "carol", Text: "Nice"}}},
+		hnscrapertest.Story{ID: 2, Title: "Go generics", By: "bob", Score: 5, Posted: posted},
+		hnscrapertest.Story{ID: 3, Title: "New arrival", By: "dave", Score: 1, Posted: posted},
+	)
+	// Wait for a poll that started after the change
+	<-polled
+	<-polled
+	if err := m.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(scores) != 1 || scores[0] != (change{1, 10, 25}) {
+		t.Error("reported score changes ", scores)
+	}
+	if len(counts) != 1 || counts[0] != (change{1, 0, 1}) {
+		t.Error("reported comment count changes ", counts)
+	}
+	if len(titles) != 1 || titles[0] != (change{1, "Rust in production", "Rust in production (2021)"}) {
+		t.Error("reported title changes ", titles)
+	}
+}
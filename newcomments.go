@@ -0,0 +1,70 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+var (
+	newCommentRowsExpr = xpath.MustCompile("//tr[contains(@class, 'athing')]")
+	onStoryExpr        = xpath.MustCompile("//span[contains(@class, 'onstory')]/a")
+)
+
+// ScrapeNewComments scrapes a page of the most recent comments across the whole site, newest first.
+// Use '1' for the latest page. The comments have no replies, and their Depth is always 0.
+func ScrapeNewComments(pageNum int) ([]Comment, error) {
+	return defaultScraper.ScrapeNewComments(pageNum)
+}
+
+// ScrapeNewComments scrapes a page of the most recent comments across the whole site, newest first.
+// Use '1' for the latest page. The comments have no replies, and their Depth is always 0.
+func (s *Scraper) ScrapeNewComments(pageNum int) ([]Comment, error) {
+	if pageNum < 1 {
+		return nil, errors.New("page number must be a positive integer")
+	}
+
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	var comments []Comment
+	_, err := s.fetchPage(ctx, s.baseURL+"newcomments?p="+strconv.Itoa(pageNum), func(ctx context.Context, doc *html.Node) error {
+		retrievedTime := time.Now()
+		for _, node := range htmlquery.QuerySelectorAll(doc, newCommentRowsExpr) {
+			comment, err := s.getComment(node, retrievedTime)
+			if err != nil {
+				return stageError("comment", err)
+			}
+
+			comment.ParentID = getParentID(node)
+			story := htmlquery.QuerySelector(node, onStoryExpr)
+			if story != nil {
+				comment.StoryID = getLinkedID(story)
+				// The link text is shortened for long titles, but the title attribute never is
+				comment.StoryTitle = firstNonEmpty(htmlquery.SelectAttr(story, "title"), htmlquery.InnerText(story))
+			}
+
+			comments = append(comments, comment)
+		}
+		return nil
+	})
+
+	return comments, err
+}
+
+// getLinkedID returns the item ID an "item?id=" link points at, or 0 if it points elsewhere.
+func getLinkedID(link *html.Node) int {
+	href := htmlquery.SelectAttr(link, "href")
+	if !strings.HasPrefix(href, "item?id=") {
+		return 0
+	}
+	id, _ := strconv.Atoi(strings.TrimPrefix(href, "item?id="))
+
+	return id
+}
@@ -0,0 +1,39 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestScrapeNewComments(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/newcomments.html"))
+	comments, err := s.ScrapeNewComments(1)
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if len(comments) != 2 {
+		t.Error("returned ", len(comments), " comments instead of 2")
+		return
+	}
+
+	first := comments[0]
+	if first.ID != 28893501 || first.By != "carol" || first.ParentID != 28893400 || first.StoryID != 28888001 ||
+		first.StoryTitle != "Show HN: A scraper for HackerNews" || first.Text != "I've been looking for this.\n\nDoes it handle job posts?" {
+		t.Errorf("parsed comment incorrectly: %+v", first)
+	}
+}
+
+func TestScrapeNewCommentsPageError(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><table><tr class="athing" id="1"><td>Nothing here</td></tr></table></body></html>`))
+	})
+
+	var pageErr *PageError
+	if _, err := s.ScrapeNewComments(1); !errors.As(err, &pageErr) || pageErr.Status != http.StatusOK {
+		t.Error("returned ", err, " instead of a PageError")
+	}
+}
@@ -0,0 +1,60 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/thetallpaul/hnscraper/googleauth"
+)
+
+// GCSScope is the OAuth2 scope the service account's credentials need to upload to GCS.
+const GCSScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+const defaultGCSURL = "https://storage.googleapis.com/"
+
+// GCS is a Google Cloud Storage bucket.
+type GCS struct {
+	Bucket      string                  // The bucket's name
+	Credentials *googleauth.Credentials // A service account with the GCSScope
+	Client      *http.Client            // The client requests are made with, nil for http.DefaultClient
+	APIURL      string                  // The storage API's base URL, the Google endpoint if empty
+}
+
+// Put uploads the object.
+func (b *GCS) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	apiURL := b.APIURL
+	if apiURL == "" {
+		apiURL = defaultGCSURL
+	}
+	uploadURL := apiURL + "upload/storage/v1/b/" + url.PathEscape(b.Bucket) +
+		"/o?uploadType=media&name=" + url.QueryEscape(key)
+
+	token, err := b.Credentials.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GCS responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package objstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thetallpaul/hnscraper/googleauth"
+)
+
+func TestGCSPut(t *testing.T) {
+	var name, body string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "secret", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/upload/storage/v1/b/hn/o", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.URL.Query().Get("uploadType") != "media" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		name, body = r.URL.Query().Get("name"), string(data)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	b := &GCS{
+		Bucket:      "hn",
+		Credentials: &googleauth.Credentials{Email: "scraper@example.com", Key: key, TokenURL: server.URL + "/token"},
+		APIURL:      server.URL + "/",
+	}
+
+	if err := b.Put(context.Background(), "exports/a b.ndjson", []byte("{}\n"), "application/x-ndjson"); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if name != "exports/a b.ndjson" || body != "{}\n" {
+		t.Error("uploaded ", body, " as ", name)
+	}
+}
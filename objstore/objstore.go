@@ -0,0 +1,111 @@
+// Package objstore writes exports and raw HTML archives to S3 or Google Cloud Storage buckets,
+// for scrapers that run without a persistent disk.
+package objstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// A Bucket stores objects. S3 and GCS are Buckets.
+type Bucket interface {
+	// Put stores the body under the key, replacing any object already there.
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// ArchiveFunc returns a function for hnscraper.WithArchiveFunc that uploads every archived page
+// to the bucket under the prefix, ie. "archive/", gzipped and with a ".gz" suffix if compress is set.
+func ArchiveFunc(b Bucket, prefix string, compress bool) func(name string, body []byte) error {
+	return func(name string, body []byte) error {
+		contentType := "text/html; charset=utf-8"
+		if compress {
+			var err error
+			if body, err = gzipBytes(body); err != nil {
+				return err
+			}
+			name += ".gz"
+			contentType = "application/gzip"
+		}
+
+		return b.Put(context.Background(), prefix+name, body, contentType)
+	}
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// A Sink is a pipeline.Sink that collects posts as newline-delimited JSON and uploads them
+// as one object when it is closed. Each time it is closed starts a new object, so a Sink can be
+// reused by a scheduled job to upload one export per run.
+type Sink struct {
+	Bucket   Bucket // Where exports are uploaded
+	Prefix   string // Prepended to every object's key, ie. "exports/frontpage-"
+	Compress bool   // Whether to gzip exports, adding a ".gz" suffix
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+	n   int // How many posts are buffered
+}
+
+// Write buffers the post.
+func (s *Sink) Write(ctx context.Context, post hnscraper.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+	s.buf.Write(data)
+	s.buf.WriteByte('\n')
+	s.n++
+
+	return nil
+}
+
+// Close uploads the buffered posts, if there are any, to an object named after the current time,
+// ie. "exports/frontpage-20211016T120000Z.ndjson".
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.n == 0 {
+		return nil
+	}
+
+	key := s.Prefix + time.Now().UTC().Format("20060102T150405Z") + ".ndjson"
+	body := s.buf.Bytes()
+	contentType := "application/x-ndjson"
+	if s.Compress {
+		var err error
+		if body, err = gzipBytes(body); err != nil {
+			return err
+		}
+		key += ".gz"
+		contentType = "application/gzip"
+	}
+
+	if err := s.Bucket.Put(context.Background(), key, body, contentType); err != nil {
+		return err
+	}
+	s.buf.Reset()
+	s.n = 0
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package objstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/pipeline"
+)
+
+// memBucket keeps objects in memory.
+type memBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memBucket) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.objects == nil {
+		b.objects = make(map[string][]byte)
+	}
+	b.objects[key] = body
+	return nil
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	return string(out)
+}
+
+func TestSink(t *testing.T) {
+	bucket := &memBucket{}
+	sink := &Sink{Bucket: bucket, Prefix: "exports/", Compress: true}
+
+	posts := []hnscraper.Post{{ID: 1, Title: "First"}, {ID: 2, Title: "Second"}}
+	if err := pipeline.New(pipeline.Posts(posts)).To(sink).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+	// Nothing new to upload
+	if err := sink.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	if len(bucket.objects) != 1 {
+		t.Fatal("uploaded ", len(bucket.objects), " objects instead of 1")
+	}
+	for key, body := range bucket.objects {
+		if !strings.HasPrefix(key, "exports/") || !strings.HasSuffix(key, ".ndjson.gz") {
+			t.Error("uploaded export as ", key)
+		}
+		if lines := strings.Split(strings.TrimSpace(gunzip(t, body)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"Second"`) {
+			t.Error("uploaded ", lines)
+		}
+	}
+}
+
+func TestArchiveFunc(t *testing.T) {
+	bucket := &memBucket{}
+	archive := ArchiveFunc(bucket, "archive/", true)
+
+	if err := archive("20211016T120000.000000000Z_news%3Fp%3D1.html", []byte("<html></html>")); err != nil {
+		t.Fatal("error: ", err)
+	}
+	body, ok := bucket.objects["archive/20211016T120000.000000000Z_news%3Fp%3D1.html.gz"]
+	if !ok || gunzip(t, body) != "<html></html>" {
+		t.Error("uploaded ", bucket.objects)
+	}
+}
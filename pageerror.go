@@ -0,0 +1,147 @@
+package hnscraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// snippetSize is how much of a failed page's body PageError.Snippet holds
+const snippetSize = 256
+
+// A PageError reports a page that was retrieved but could not be parsed, with what is needed to debug it remotely.
+type PageError struct {
+	URL      string // The page that failed
+	Status   int    // The HTTP status the page was served with. Error statuses fail before parsing, with their own error
+	Stage    string // The part of the page that couldn't be parsed, ie. "subtext" or "score"
+	Size     int    // The length of the body in bytes
+	BodyHash string // The start of the hex SHA-256 of the body, to tell apart pages that failed differently
+	Snippet  string // The start of the body
+	DumpPath string // Where the whole body was saved, empty unless WithDebugDir is set
+	Err      error  // The underlying parse error
+}
+
+func (e *PageError) Error() string {
+	msg := fmt.Sprintf("%s: could not parse %s (status %d, %d bytes, sha256 %s)", e.Stage, e.URL, e.Status, e.Size, e.BodyHash)
+	if e.DumpPath != "" {
+		msg += ", saved to " + e.DumpPath
+	}
+
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// WithDebugDir saves the HTML of every page that fails to parse to the directory, recording the file in PageError.DumpPath.
+func WithDebugDir(dir string) Option {
+	return func(s *Scraper) {
+		s.debugDir = dir
+	}
+}
+
+// parseError marks which stage of parsing a page failed at.
+type parseError struct {
+	stage string
+	err   error
+}
+
+func (e *parseError) Error() string {
+	return e.stage + ": " + e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// stageError attributes err to the stage, unless a more specific stage already claimed it.
+func stageError(stage string, err error) error {
+	var pe *parseError
+	if errors.As(err, &pe) {
+		return err
+	}
+
+	return &parseError{stage: stage, err: err}
+}
+
+// fetchPage fetches a page like fetch and hands the document to parse, turning parse failures into a *PageError.
+// parse is given a context holding the parse span.
+// It returns how the page was served.
+func (s *Scraper) fetchPage(ctx context.Context, pageURL string, parse func(ctx context.Context, doc *html.Node) error) (PageMeta, error) {
+	var meta PageMeta
+	var body []byte
+	var doc *html.Node
+	err := s.fetchBody(ctx, pageURL, true, func(r io.Reader) (err error) {
+		if mr, ok := r.(*metaReader); ok {
+			meta = mr.meta
+		}
+		if body, err = io.ReadAll(r); err != nil {
+			return err
+		}
+		meta.Bytes = len(body)
+		if doc, err = htmlquery.Parse(bytes.NewReader(body)); err == nil {
+			s.checkPooledSession(r, doc)
+		}
+		return err
+	})
+	if err != nil {
+		return meta, err
+	}
+
+	ctx, span := s.startSpan(ctx, SpanParse)
+	span.SetString("url", pageURL)
+	span.SetInt("bytes", len(body))
+	err = parse(ctx, doc)
+	span.End(err)
+	var pe *parseError
+	if err == nil || !errors.As(err, &pe) {
+		return meta, err
+	}
+
+	sum := sha256.Sum256(body)
+	snippet := body
+	if len(snippet) > snippetSize {
+		snippet = snippet[:snippetSize]
+	}
+	// Don't cut the snippet in the middle of a character
+	for len(snippet) > 0 && !utf8.Valid(snippet) {
+		snippet = snippet[:len(snippet)-1]
+	}
+	pageErr := &PageError{
+		URL:      pageURL,
+		Status:   meta.Status,
+		Stage:    pe.stage,
+		Size:     len(body),
+		BodyHash: hex.EncodeToString(sum[:8]),
+		Snippet:  string(snippet),
+		Err:      pe.err,
+	}
+
+	if s.debugDir != "" {
+		name := time.Now().UTC().Format(archiveTimeLayout) + "_" + url.QueryEscape(strings.TrimPrefix(pageURL, s.baseURL)) + ".html"
+		path := filepath.Join(s.debugDir, name)
+		if err := os.MkdirAll(s.debugDir, 0o755); err != nil {
+			s.logf("could not save failed page %s: %v", pageURL, err)
+		} else if err := os.WriteFile(path, body, 0o644); err != nil {
+			s.logf("could not save failed page %s: %v", pageURL, err)
+		} else {
+			pageErr.DumpPath = path
+		}
+	}
+
+	return meta, pageErr
+}
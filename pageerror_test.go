@@ -0,0 +1,44 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPageError(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	broken := strings.Replace(string(fixture), "312 points", "many points", 1)
+	dir := t.TempDir()
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/item" {
+			w.Write([]byte("<html><body><p>Nothing to see here</p></body></html>"))
+			return
+		}
+		w.Write([]byte(broken))
+	}, WithDebugDir(dir))
+
+	_, err = s.ScrapePage(1)
+	var pageErr *PageError
+	if !errors.As(err, &pageErr) {
+		t.Fatal("returned ", err, " instead of a *PageError")
+	}
+	if pageErr.Stage != "score" || pageErr.Status != http.StatusOK || pageErr.Size != len(broken) ||
+		len(pageErr.BodyHash) != 16 || !strings.HasPrefix(pageErr.Snippet, "<html") || !strings.HasSuffix(pageErr.URL, "news?p=1") {
+		t.Errorf("reported failure incorrectly: %+v", pageErr)
+	}
+	dumped, err := os.ReadFile(pageErr.DumpPath)
+	if err != nil || string(dumped) != broken {
+		t.Error("didn't save the failed page: ", err)
+	}
+
+	if _, err := s.ScrapeItem(1); !errors.As(err, &pageErr) || pageErr.Stage != "item" {
+		t.Error("returned ", err, " for an item page without the item")
+	}
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// maxBatch is the batch size used when Batch is given none, so posts can't pile up without limit between flushes.
+const maxBatch = 1000
+
+// A BatchFunc handles a batch of posts, ie. by inserting them into a database in one statement.
+type BatchFunc func(ctx context.Context, posts []hnscraper.Post) error
+
+// batchSink collects posts and hands them to fn in batches.
+type batchSink struct {
+	size int
+	fn   BatchFunc
+
+	mu    sync.Mutex // Held while flushing, so Write waits for a slow fn instead of buffering more
+	batch []hnscraper.Post
+	ctx   context.Context // The context of the latest Write, used for timed flushes
+	err   error           // The error from a timed flush, reported by the next Write or Close
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Batch is a Sink that hands posts to fn in batches of size, and also every interval if posts are waiting,
+// so a quiet source doesn't leave posts sitting in a half-full batch. An interval of 0 disables that trigger, and a size
+// of 0 means batches of up to 1000 posts; whatever is left is flushed by Close.
+// At most size posts are held at once: Write blocks while fn runs, which holds back the rest of the pipeline.
+func Batch(size int, every time.Duration, fn BatchFunc) Sink {
+	if size <= 0 {
+		size = maxBatch
+	}
+	s := &batchSink{size: size, fn: fn, ctx: context.Background(), stop: make(chan struct{}), done: make(chan struct{})}
+	if every <= 0 {
+		close(s.done)
+		return s
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if s.err == nil {
+					s.err = s.flush(s.ctx)
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *batchSink) Write(ctx context.Context, post hnscraper.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.ctx = ctx
+	s.batch = append(s.batch, post)
+	if len(s.batch) >= s.size {
+		return s.flush(ctx)
+	}
+
+	return nil
+}
+
+// flush hands the waiting posts to fn. The caller must hold mu.
+func (s *batchSink) flush(ctx context.Context) error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	batch := s.batch
+	s.batch = nil
+
+	return s.fn(ctx, batch)
+}
+
+func (s *batchSink) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	// The pipeline's context may already be cancelled, but the last batch should still be delivered
+	return s.flush(context.Background())
+}
@@ -0,0 +1,165 @@
+// Package pipeline assembles scraping flows out of composable stages:
+// a Source produces posts, Transforms filter or change them, and Sinks store them.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// A Source produces posts, sending each one to out until it runs out of posts or ctx is done.
+// The pipeline closes out once Run returns.
+type Source interface {
+	Run(ctx context.Context, out chan<- hnscraper.Post) error
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func(ctx context.Context, out chan<- hnscraper.Post) error
+
+// Run calls f(ctx, out).
+func (f SourceFunc) Run(ctx context.Context, out chan<- hnscraper.Post) error {
+	return f(ctx, out)
+}
+
+// A Transform filters or changes a single post. It returns false to drop the post from the pipeline.
+type Transform interface {
+	Apply(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error)
+}
+
+// TransformFunc adapts a function to a Transform.
+type TransformFunc func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error)
+
+// Apply calls f(ctx, post).
+func (f TransformFunc) Apply(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+	return f(ctx, post)
+}
+
+// A Sink stores the posts that make it through the pipeline.
+type Sink interface {
+	Write(ctx context.Context, post hnscraper.Post) error
+	Close() error // Flushes anything buffered once the pipeline is done
+}
+
+// A Pipeline runs posts from a Source through its Transforms, in order, and writes the survivors to every Sink.
+// Stages hand posts along one at a time, so a slow sink holds up the source rather than posts piling up in memory.
+type Pipeline struct {
+	source     Source
+	transforms []Transform
+	sinks      []Sink
+	buffer     int              // How many posts the source can get ahead of the rest of the pipeline
+	tracer     hnscraper.Tracer // Traces every sink write, nil to disable
+}
+
+// SpanWrite is the span a traced pipeline starts for every sink write, with the attributes "sink" and "id".
+const SpanWrite = "pipeline.write"
+
+// New creates a Pipeline reading from the source.
+func New(source Source) *Pipeline {
+	return &Pipeline{source: source}
+}
+
+// Then adds transforms to the end of the pipeline, returning the pipeline for chaining.
+func (p *Pipeline) Then(transforms ...Transform) *Pipeline {
+	p.transforms = append(p.transforms, transforms...)
+	return p
+}
+
+// To adds sinks to the pipeline, returning the pipeline for chaining.
+func (p *Pipeline) To(sinks ...Sink) *Pipeline {
+	p.sinks = append(p.sinks, sinks...)
+	return p
+}
+
+// Buffer lets the source get up to n posts ahead of the transforms and sinks, smoothing out bursts such as
+// a whole page arriving at once, while still holding the source back once n posts are waiting.
+// It returns the pipeline for chaining.
+func (p *Pipeline) Buffer(n int) *Pipeline {
+	p.buffer = n
+	return p
+}
+
+// Trace traces every sink write with the tracer, see SpanWrite. Give the pipeline's scraper the same tracer with
+// hnscraper.WithTracer to trace the scrapes feeding it too. It returns the pipeline for chaining.
+func (p *Pipeline) Trace(tracer hnscraper.Tracer) *Pipeline {
+	p.tracer = tracer
+	return p
+}
+
+// Run runs the pipeline until the source runs out of posts, a stage fails, or ctx is done.
+// Every sink is closed before Run returns, even if a stage failed. If ctx is done first, its error is returned.
+func (p *Pipeline) Run(parent context.Context) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	posts := make(chan hnscraper.Post, p.buffer)
+	sourceErr := make(chan error, 1)
+	go func() {
+		defer close(posts)
+		sourceErr <- p.source.Run(ctx, posts)
+	}()
+
+	err := p.process(ctx, posts)
+	// Stop the source early if a later stage failed, then wait for it to finish
+	cancel()
+	for range posts {
+	}
+	// The source seeing the pipeline's own cancel isn't an error, but the caller's is
+	if srcErr := <-sourceErr; err == nil && !errors.Is(srcErr, context.Canceled) {
+		err = srcErr
+	}
+	if parentErr := parent.Err(); parentErr != nil && err == nil {
+		err = parentErr
+	}
+
+	for _, sink := range p.sinks {
+		if closeErr := sink.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+func (p *Pipeline) process(ctx context.Context, posts <-chan hnscraper.Post) error {
+	for post := range posts {
+		keep := true
+		var err error
+		for _, transform := range p.transforms {
+			post, keep, err = transform.Apply(ctx, post)
+			if err != nil {
+				return err
+			} else if !keep {
+				break
+			}
+		}
+		if !keep {
+			continue
+		}
+
+		for _, sink := range p.sinks {
+			if err := p.write(ctx, sink, post); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// write writes the post to the sink, in a span if the pipeline is traced.
+func (p *Pipeline) write(ctx context.Context, sink Sink, post hnscraper.Post) error {
+	if p.tracer == nil {
+		return sink.Write(ctx, post)
+	}
+
+	ctx, span := p.tracer.Start(ctx, SpanWrite)
+	span.SetString("sink", fmt.Sprintf("%T", sink))
+	span.SetInt("id", post.ID)
+	err := sink.Write(ctx, post)
+	span.End(err)
+
+	return err
+}
@@ -0,0 +1,343 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+var posts = []hnscraper.Post{
+	{ID: 1, Title: "First", Score: 100, By: "alice"},
+	{ID: 2, Title: "Second", Score: 5, By: "bob"},
+	{ID: 1, Title: "First", Score: 101, By: "alice"},
+	{ID: 3, Title: "Third", Score: 50, By: "carol"},
+}
+
+func TestPipeline(t *testing.T) {
+	var out bytes.Buffer
+	collector := &Collector{}
+
+	err := New(Posts(posts)).
+		Then(Dedupe(hnscraper.NewDeduper(false)), Filter(filter.ByMinScore(10))).
+		To(JSON(&out), collector).
+		Run(context.Background())
+
+	if err != nil {
+		t.Error("error: ", err)
+		return
+	}
+	if got := collector.Posts(); len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Error("collected ", len(got), " posts instead of posts 1 and 3")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var decoded hnscraper.Post
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &decoded) != nil || decoded.ID != 3 {
+		t.Error("wrote incorrect JSON: ", out.String())
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	failure := errors.New("transform failed")
+	collector := &Collector{}
+
+	err := New(Posts(posts)).
+		Then(TransformFunc(func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+			if post.ID == 2 {
+				return post, false, failure
+			}
+			return post, true, nil
+		})).
+		To(collector).
+		Run(context.Background())
+
+	if !errors.Is(err, failure) {
+		t.Error("returned ", err, " instead of the transform's error")
+	}
+	if len(collector.Posts()) != 1 {
+		t.Error("kept writing after a stage failed")
+	}
+}
+
+func TestPipelineCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	source := SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := New(source).To(&Collector{}).Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Error("returned ", err, " instead of the caller's cancellation")
+	}
+}
+
+func TestGob(t *testing.T) {
+	var out bytes.Buffer
+	if err := New(Posts(posts)).To(Gob(&out)).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	dec := gob.NewDecoder(&out)
+	for _, want := range posts {
+		var got hnscraper.Post
+		if err := dec.Decode(&got); err != nil {
+			t.Fatal("error: ", err)
+		}
+		if !got.Equal(want) {
+			t.Error("decoded ", got, " instead of ", want)
+		}
+	}
+}
+
+func TestGzip(t *testing.T) {
+	var out bytes.Buffer
+	if err := New(Posts(posts)).To(Gzip(&out, JSON)).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != len(posts) {
+		t.Error("decompressed ", len(lines), " lines instead of ", len(posts))
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var post hnscraper.Post
+		if r.Method != http.MethodPost || json.Unmarshal(body, &post) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&received, 1)
+	}))
+	defer server.Close()
+
+	if err := New(Posts(posts)).To(Webhook(server.URL, nil)).Run(context.Background()); err != nil {
+		t.Error("error: ", err)
+	}
+	if atomic.LoadInt32(&received) != int32(len(posts)) {
+		t.Error("webhook received ", received, " posts instead of ", len(posts))
+	}
+}
+
+func TestArchive(t *testing.T) {
+	html, err := os.ReadFile("../testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{
+		"20211016T130000.000000000Z_news%3Fp%3D2.html",
+		"20211016T120000.000000000Z_news%3Fp%3D1.html",
+		"20211016T120500.000000000Z_item%3Fid%3D1.html",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), html, 0o644); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+
+	collector := &Collector{}
+	if err := New(Archive(hnscraper.NewScraper(), dir)).To(collector).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if got := collector.Posts(); len(got) != 6 || got[0].ID != 28888001 {
+		t.Error("replayed ", len(got), " posts instead of 6")
+	}
+}
+
+// serveLocally answers every request the client makes with the handler instead of going over the network.
+type serveLocally http.HandlerFunc
+
+func (f serveLocally) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	f(rec, r)
+	return rec.Result(), nil
+}
+
+func TestAuthors(t *testing.T) {
+	requests := map[string]int{}
+	client := &http.Client{Transport: serveLocally(func(w http.ResponseWriter, r *http.Request) {
+		user := r.URL.Query().Get("id")
+		requests[user]++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if user != "alice" {
+			w.Write([]byte("No such user."))
+			return
+		}
+		http.ServeFile(w, r, "../testdata/user.html")
+	})}
+	scraper := hnscraper.NewScraper(hnscraper.WithHTTPClient(client))
+
+	collector := &Collector{}
+	if err := New(Posts(posts)).Then(Authors(scraper)).To(collector).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	if requests["alice"] != 1 || requests["bob"] != 1 {
+		t.Error("scraped profiles ", requests, " instead of once per author")
+	}
+	for _, post := range collector.Posts() {
+		if enriched := post.AuthorInfo.Karma == 4821; enriched != (post.By == "alice") {
+			t.Error("enriched post ", post.ID, " by ", post.By, " with ", post.AuthorInfo)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var batches [][]int
+	sink := Batch(3, 0, func(ctx context.Context, posts []hnscraper.Post) error {
+		var ids []int
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		batches = append(batches, ids)
+		return nil
+	})
+
+	if err := New(Posts(posts)).To(sink).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 1 || batches[1][0] != 3 {
+		t.Error("wrote batches ", batches, " instead of three posts and then one")
+	}
+}
+
+func TestBatchUnsized(t *testing.T) {
+	var sizes []int
+	sink := Batch(0, time.Hour, func(ctx context.Context, posts []hnscraper.Post) error {
+		sizes = append(sizes, len(posts))
+		return nil
+	})
+
+	for i := 0; i < maxBatch+1; i++ {
+		if err := sink.Write(context.Background(), hnscraper.Post{ID: i}); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Error("closing again returned ", err)
+	}
+	if len(sizes) != 2 || sizes[0] != maxBatch || sizes[1] != 1 {
+		t.Error("wrote batches of ", sizes, " instead of a full batch and then one")
+	}
+}
+
+func TestBatchInterval(t *testing.T) {
+	flushed := make(chan int, 10)
+	sink := Batch(100, 10*time.Millisecond, func(ctx context.Context, posts []hnscraper.Post) error {
+		flushed <- len(posts)
+		return nil
+	})
+
+	// A quiet source: one post, then nothing until the batch has been flushed on time
+	source := SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		if err := send(ctx, out, posts[0]); err != nil {
+			return err
+		}
+		select {
+		case n := <-flushed:
+			if n != 1 {
+				t.Error("flushed ", n, " posts instead of 1")
+			}
+		case <-time.After(time.Second):
+			t.Error("didn't flush the waiting post on time")
+		}
+		return nil
+	})
+	if err := New(source).To(sink).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+}
+
+func TestBackPressure(t *testing.T) {
+	var sent int32
+	source := SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		for i := 0; i < 100; i++ {
+			if err := send(ctx, out, hnscraper.Post{ID: i}); err != nil {
+				return err
+			}
+			atomic.AddInt32(&sent, 1)
+		}
+		return nil
+	})
+
+	release := make(chan struct{})
+	sink := Batch(1, 0, func(ctx context.Context, posts []hnscraper.Post) error {
+		<-release
+		return nil
+	})
+
+	result := make(chan error, 1)
+	go func() { result <- New(source).Buffer(5).To(sink).Run(context.Background()) }()
+
+	time.Sleep(50 * time.Millisecond)
+	// One post is stuck in the sink and five wait in the buffer
+	if n := atomic.LoadInt32(&sent); n > 7 {
+		t.Error("source ran ", n, " posts ahead of a stuck sink")
+	}
+	close(release)
+	if err := <-result; err != nil {
+		t.Error("error: ", err)
+	}
+	if n := atomic.LoadInt32(&sent); n != 100 {
+		t.Error("sent ", n, " posts once the sink caught up")
+	}
+}
+
+// countingTracer counts the spans it starts by name.
+type countingTracer struct {
+	mu    sync.Mutex
+	spans map[string]int
+}
+
+func (t *countingTracer) Start(ctx context.Context, name string) (context.Context, hnscraper.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans[name]++
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetString(key, value string)  {}
+func (nopSpan) SetInt(key string, value int) {}
+func (nopSpan) End(err error)                {}
+
+func TestTrace(t *testing.T) {
+	tracer := &countingTracer{spans: make(map[string]int)}
+	if err := New(Posts(posts)).To(&Collector{}, &Collector{}).Trace(tracer).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if tracer.spans[SpanWrite] != 2*len(posts) {
+		t.Error("started spans ", tracer.spans, " for ", len(posts), " posts written to two sinks")
+	}
+}
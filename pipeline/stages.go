@@ -0,0 +1,292 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+// send forwards a post to out, giving up if ctx is done first.
+func send(ctx context.Context, out chan<- hnscraper.Post, post hnscraper.Post) error {
+	select {
+	case out <- post:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Posts is a Source that produces the given posts.
+func Posts(posts []hnscraper.Post) Source {
+	return SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		for _, post := range posts {
+			if err := send(ctx, out, post); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Pages is a Source that scrapes the pages from startPage to endPage, inclusive, with the scraper.
+func Pages(scraper *hnscraper.Scraper, startPage, endPage int) Source {
+	return SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		for i := startPage; i <= endPage; i++ {
+			page, err := scraper.ScrapePageContext(ctx, i)
+			if err != nil {
+				return err
+			}
+			for _, post := range page.Posts {
+				if err := send(ctx, out, post); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Archive is a Source that replays the listing pages archived in dir by hnscraper.WithArchiveDir,
+// parsing them with the scraper in the order they were retrieved.
+func Archive(scraper *hnscraper.Scraper, dir string) Source {
+	return SourceFunc(func(ctx context.Context, out chan<- hnscraper.Post) error {
+		entries, err := hnscraper.ReadArchive(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Page, "news") {
+				continue
+			}
+			page, err := scraper.ParseArchivedContext(ctx, entry)
+			if err != nil {
+				return err
+			}
+			for _, post := range page.Posts {
+				if err := send(ctx, out, post); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Filter is a Transform that drops the posts the filter doesn't keep.
+func Filter(f filter.Filter) Transform {
+	return TransformFunc(func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+		return post, f(post), nil
+	})
+}
+
+// Dedupe is a Transform that drops the posts the deduper has already seen.
+func Dedupe(d *hnscraper.Deduper) Transform {
+	return TransformFunc(func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+		return post, !d.Seen(post), nil
+	})
+}
+
+// authorCache remembers the profile of every author looked up by Authors.
+type authorCache struct {
+	scraper *hnscraper.Scraper
+	mu      sync.Mutex // Held for the whole lookup, so each author is only scraped once
+	authors map[string]hnscraper.AuthorInfo
+}
+
+// Authors is a Transform that sets Post.AuthorInfo from the submitter's profile, scraped with the scraper.
+// Each author is only scraped once however many of their posts pass through, so the transform should be reused
+// across runs to keep the cache. Posts without a submitter, and submitters whose accounts no longer exist,
+// are left without AuthorInfo.
+func Authors(scraper *hnscraper.Scraper) Transform {
+	cache := &authorCache{scraper: scraper, authors: make(map[string]hnscraper.AuthorInfo)}
+	return TransformFunc(cache.apply)
+}
+
+func (c *authorCache) apply(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+	if post.By == "" {
+		return post, true, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, ok := c.authors[post.By]
+	if !ok {
+		user, err := c.scraper.ScrapeUser(post.By)
+		if err != nil && !errors.Is(err, hnscraper.ErrUserNotFound) {
+			return post, false, err
+		}
+		info = hnscraper.AuthorInfo{Karma: user.Karma, Created: user.Created}
+		c.authors[post.By] = info
+	}
+	post.AuthorInfo = info
+
+	return post, true, nil
+}
+
+// Language is a Transform that sets Post.Lang to the detected language of the post's title.
+// Combine it with filter.ByLang to keep only titles in some languages.
+func Language() Transform {
+	return TransformFunc(func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+		post.Lang = hnscraper.DetectLanguage(post.Title)
+		return post, true, nil
+	})
+}
+
+// Tag is a Transform that sets Post.Tags with the tagger.
+func Tag(tagger *hnscraper.Tagger) Transform {
+	return TransformFunc(func(ctx context.Context, post hnscraper.Post) (hnscraper.Post, bool, error) {
+		return tagger.Tag(post), true, nil
+	})
+}
+
+// jsonSink writes posts as newline-delimited JSON.
+type jsonSink struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// JSON is a Sink that writes each post to w as a line of JSON.
+func JSON(w io.Writer) Sink {
+	buffered := bufio.NewWriter(w)
+	return &jsonSink{w: buffered, enc: json.NewEncoder(buffered)}
+}
+
+func (s *jsonSink) Write(ctx context.Context, post hnscraper.Post) error {
+	return s.enc.Encode(post)
+}
+
+func (s *jsonSink) Close() error {
+	return s.w.Flush()
+}
+
+// gobSink writes posts as a gob stream.
+type gobSink struct {
+	w   *bufio.Writer
+	enc *gob.Encoder
+}
+
+// Gob is a Sink that writes the posts to w as a single gob stream, a compact binary alternative to JSON.
+// The stream can be read back with gob.Decoder, decoding one hnscraper.Post at a time.
+func Gob(w io.Writer) Sink {
+	buffered := bufio.NewWriter(w)
+	return &gobSink{w: buffered, enc: gob.NewEncoder(buffered)}
+}
+
+func (s *gobSink) Write(ctx context.Context, post hnscraper.Post) error {
+	return s.enc.Encode(post)
+}
+
+func (s *gobSink) Close() error {
+	return s.w.Flush()
+}
+
+// gzipSink compresses what another sink writes.
+type gzipSink struct {
+	Sink
+	zw *gzip.Writer
+}
+
+// Gzip wraps a sink that writes to an io.Writer, such as JSON or Gob, so its output is gzipped before reaching w,
+// ie. pipeline.Gzip(file, pipeline.JSON). Closing the sink finishes the gzip stream but doesn't close w.
+func Gzip(w io.Writer, sink func(io.Writer) Sink) Sink {
+	zw := gzip.NewWriter(w)
+	return &gzipSink{Sink: sink(zw), zw: zw}
+}
+
+func (s *gzipSink) Close() error {
+	if err := s.Sink.Close(); err != nil {
+		return err
+	}
+
+	return s.zw.Close()
+}
+
+// webhookSink posts each post to a URL as JSON.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// Webhook is a Sink that sends each post to the URL as the JSON body of a POST request.
+// A nil client uses http.DefaultClient.
+func Webhook(url string, client *http.Client) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &webhookSink{url: url, client: client}
+}
+
+func (s *webhookSink) Write(ctx context.Context, post hnscraper.Post) error {
+	body, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
+
+// A Collector is a Sink that keeps every post in memory. It is safe for concurrent use.
+type Collector struct {
+	mu    sync.Mutex
+	posts []hnscraper.Post
+}
+
+// Write keeps the post.
+func (c *Collector) Write(ctx context.Context, post hnscraper.Post) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.posts = append(c.posts, post)
+	return nil
+}
+
+// Close does nothing.
+func (c *Collector) Close() error {
+	return nil
+}
+
+// Posts returns the posts written so far.
+func (c *Collector) Posts() []hnscraper.Post {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]hnscraper.Post(nil), c.posts...)
+}
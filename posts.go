@@ -0,0 +1,166 @@
+package hnscraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Posts is a collection of posts that can be sorted.
+// Every sort is stable and sorts in place, returning the collection so sorts can be chained.
+// The last sort in a chain decides the primary order, with earlier sorts breaking ties.
+type Posts []Post
+
+// SortByScore orders the posts from highest to lowest score.
+func (p Posts) SortByScore() Posts {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].Score > p[j].Score
+	})
+
+	return p
+}
+
+// SortByComments orders the posts from most to fewest comments.
+func (p Posts) SortByComments() Posts {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].NumComments > p[j].NumComments
+	})
+
+	return p
+}
+
+// SortByTime orders the posts from newest to oldest.
+func (p Posts) SortByTime() Posts {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].TimePosted.After(p[j].TimePosted)
+	})
+
+	return p
+}
+
+// SortByVelocity orders the posts from fastest to slowest rate of points gained per hour, measured at the given time.
+// Use the Retrieved time of the page the posts came from for an accurate ordering.
+func (p Posts) SortByVelocity(at time.Time) Posts {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].Velocity(at) > p[j].Velocity(at)
+	})
+
+	return p
+}
+
+// Velocity is the average number of points the post gained per hour between its submission and the given time.
+func (p Post) Velocity(at time.Time) float64 {
+	age := at.Sub(p.TimePosted).Hours()
+	// Avoid dividing by zero for posts submitted moments ago
+	if age < 1.0/60 {
+		age = 1.0 / 60
+	}
+
+	return float64(p.Score) / age
+}
+
+// Post types as returned by Post.Type.
+const (
+	TypeStory  = "story"
+	TypeAsk    = "ask"
+	TypeShow   = "show"
+	TypeLaunch = "launch"
+	TypeJob    = "job"
+)
+
+// Type classifies the post as one of TypeStory, TypeAsk, TypeShow, TypeLaunch, or TypeJob.
+// Job postings are recognised by Job, the rest by their title prefix.
+func (p Post) Type() string {
+	switch {
+	case p.Job:
+		return TypeJob
+	case strings.HasPrefix(p.Title, "Ask HN"):
+		return TypeAsk
+	case strings.HasPrefix(p.Title, "Show HN"):
+		return TypeShow
+	case strings.HasPrefix(p.Title, "Launch HN"):
+		return TypeLaunch
+	default:
+		return TypeStory
+	}
+}
+
+// Key returns a string that identifies the post across pages and scrapes, for use as a map key.
+// It is based on the item ID, falling back to a hash of the canonical URL and title for posts without one.
+func (p Post) Key() string {
+	if p.ID != 0 {
+		return "item/" + strconv.Itoa(p.ID)
+	}
+
+	sum := sha256.Sum256([]byte(CanonicalURL(p.URL) + "\x00" + p.Title))
+	return "hash/" + hex.EncodeToString(sum[:8])
+}
+
+// Equal reports whether every field of the two posts is the same.
+func (p Post) Equal(other Post) bool {
+	return len(p.Changed(other)) == 0
+}
+
+// Changed returns the names of the fields that differ between the two posts, ie. ["Rank", "Score"].
+func (p Post) Changed(other Post) []string {
+	var changed []string
+	check := func(field string, equal bool) {
+		if !equal {
+			changed = append(changed, field)
+		}
+	}
+
+	check("ID", p.ID == other.ID)
+	check("Rank", p.Rank == other.Rank)
+	check("Title", p.Title == other.Title)
+	check("RawTitle", p.RawTitle == other.RawTitle)
+	check("Score", p.Score == other.Score)
+	check("By", p.By == other.By)
+	check("URL", p.URL == other.URL)
+	check("NumComments", p.NumComments == other.NumComments)
+	check("TimePosted", p.TimePosted.Equal(other.TimePosted))
+	check("TimeApprox", p.TimeApprox == other.TimeApprox)
+	check("Pinned", p.Pinned == other.Pinned)
+	check("Promoted", p.Promoted == other.Promoted)
+	check("Job", p.Job == other.Job)
+	check("SecondChance", p.SecondChance == other.SecondChance)
+	check("Lang", p.Lang == other.Lang)
+	check("Tags", strings.Join(p.Tags, "\x00") == strings.Join(other.Tags, "\x00"))
+	check("Hidden", p.Hidden == other.Hidden)
+	check("HideAuth", p.HideAuth == other.HideAuth)
+	check("CommentsURL", p.CommentsURL == other.CommentsURL)
+	check("AuthorInfo", p.AuthorInfo.Karma == other.AuthorInfo.Karma && p.AuthorInfo.Created.Equal(other.AuthorInfo.Created))
+
+	return changed
+}
+
+// NewSince returns the posts on the page that aren't on the previous snapshot, in page order.
+// Posts are matched by item ID, so a post that only moved or changed score isn't new.
+func (page Page) NewSince(previous Page) []Post {
+	return missingFrom(page.Posts, previous.Posts)
+}
+
+// Dropped returns the posts on the previous snapshot that are no longer on the page, in the previous page's order.
+func (page Page) Dropped(previous Page) []Post {
+	return missingFrom(previous.Posts, page.Posts)
+}
+
+// missingFrom returns the posts that have no post with the same Key in others.
+func missingFrom(posts, others []Post) []Post {
+	seen := make(map[string]bool, len(others))
+	for _, post := range others {
+		seen[post.Key()] = true
+	}
+
+	var missing []Post
+	for _, post := range posts {
+		if !seen[post.Key()] {
+			missing = append(missing, post)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,100 @@
+package hnscraper
+
+import (
+	"testing"
+	"time"
+)
+
+var sortTime = time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func samplePosts() Posts {
+	return Posts{
+		{Rank: 1, Score: 100, NumComments: 10, TimePosted: sortTime.Add(-10 * time.Hour)},
+		{Rank: 2, Score: 50, NumComments: 80, TimePosted: sortTime.Add(-time.Hour)},
+		{Rank: 3, Score: 100, NumComments: 80, TimePosted: sortTime.Add(-2 * time.Hour)},
+		{Rank: 4, Score: 5, NumComments: 0, TimePosted: sortTime.Add(-30 * time.Minute)},
+	}
+}
+
+func checkOrder(t *testing.T, name string, posts Posts, want []int) {
+	for i, p := range posts {
+		if p.Rank != want[i] {
+			t.Error(name, ": sorted rank ", p.Rank, " into position ", i, ", wanted order ", want)
+			return
+		}
+	}
+}
+
+func TestPostsSort(t *testing.T) {
+	checkOrder(t, "score", samplePosts().SortByScore(), []int{1, 3, 2, 4})
+	checkOrder(t, "comments", samplePosts().SortByComments(), []int{2, 3, 1, 4})
+	checkOrder(t, "time", samplePosts().SortByTime(), []int{4, 2, 3, 1})
+	checkOrder(t, "velocity", samplePosts().SortByVelocity(sortTime), []int{2, 3, 1, 4})
+	checkOrder(t, "chained", samplePosts().SortByComments().SortByScore(), []int{3, 1, 2, 4})
+}
+
+func TestPostKey(t *testing.T) {
+	withID := Post{ID: 42, Title: "A", URL: "https://example.com"}
+	if withID.Key() != "item/42" {
+		t.Error("returned key ", withID.Key(), " for a post with an ID")
+	}
+
+	a := Post{Title: "A", URL: "https://www.example.com/a/"}
+	b := Post{Title: "A", URL: "https://example.com/a?utm_source=x"}
+	c := Post{Title: "B", URL: "https://example.com/a"}
+	if a.Key() != b.Key() || a.Key() == c.Key() {
+		t.Error("did not key posts without an ID by canonical URL and title")
+	}
+}
+
+func TestPostChanged(t *testing.T) {
+	old := Post{ID: 1, Rank: 3, Title: "A", Score: 10, TimePosted: sortTime}
+	updated := old
+	updated.Rank = 1
+	updated.Score = 25
+	updated.TimePosted = sortTime.In(time.FixedZone("EST", -5*60*60))
+
+	changed := old.Changed(updated)
+	if len(changed) != 2 || changed[0] != "Rank" || changed[1] != "Score" {
+		t.Error("reported changed fields ", changed, " instead of [Rank Score]")
+	}
+	if old.Equal(updated) || !old.Equal(old) {
+		t.Error("compared posts incorrectly")
+	}
+}
+
+func TestPostType(t *testing.T) {
+	tests := map[string]Post{
+		TypeStory:  {By: "alice", Title: "Rust in production"},
+		TypeAsk:    {By: "bob", Title: "Ask HN: Who is hiring?"},
+		TypeShow:   {By: "carol", Title: "Show HN: My scraper"},
+		TypeLaunch: {By: "dave", Title: "Launch HN: Acme (YC W21)"},
+		TypeJob:    {Title: "Acme is hiring engineers", Job: true},
+	}
+	for want, post := range tests {
+		if got := post.Type(); got != want {
+			t.Error("classified ", post.Title, " as ", got, " instead of ", want)
+		}
+	}
+	// Posts can lack a submitter without being jobs, ie. when it wasn't extracted or the account was deleted
+	if got := (Post{Title: "Rust in production"}).Type(); got != TypeStory {
+		t.Error("classified a post without a submitter as ", got)
+	}
+}
+
+func TestPageNewSince(t *testing.T) {
+	previous := Page{Posts: []Post{{ID: 1, Rank: 1}, {ID: 2, Rank: 2}, {ID: 3, Rank: 3}}}
+	current := Page{Posts: []Post{{ID: 4, Rank: 1}, {ID: 1, Rank: 2, Score: 50}, {ID: 3, Rank: 3}, {ID: 5, Rank: 4}}}
+
+	added := current.NewSince(previous)
+	if len(added) != 2 || added[0].ID != 4 || added[1].ID != 5 {
+		t.Error("found new posts ", added)
+	}
+	dropped := current.Dropped(previous)
+	if len(dropped) != 1 || dropped[0].ID != 2 {
+		t.Error("found dropped posts ", dropped)
+	}
+	if len(current.NewSince(current)) != 0 || len(current.Dropped(current)) != 0 {
+		t.Error("found changes between a page and itself")
+	}
+}
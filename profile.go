@@ -0,0 +1,43 @@
+package hnscraper
+
+import "time"
+
+// A Profile bundles the settings that decide how hard a Scraper leans on HackerNews, see WithProfile.
+// Start from ProfileGentle or ProfileNormal, or fill in a Profile of your own for heavier use.
+type Profile struct {
+	Interval     time.Duration // The minimum time between requests, see WithRateLimit
+	Jitter       time.Duration // The most random delay added to each interval, see WithJitter
+	Concurrency  int           // The most requests in flight at once, see WithMaxConcurrency
+	Retries      int           // How many times a failed request is retried, see WithRetries
+	RetryBackoff time.Duration // How long to wait before the first retry, doubling after
+}
+
+// ProfileGentle makes one request at a time, at most one every 5 seconds, for long-running jobs
+// that aren't in a hurry.
+var ProfileGentle = Profile{
+	Interval:     5 * time.Second,
+	Jitter:       time.Second,
+	Concurrency:  1,
+	Retries:      2,
+	RetryBackoff: 10 * time.Second,
+}
+
+// ProfileNormal makes at most one request a second, with a little concurrency for ScrapeItems.
+var ProfileNormal = Profile{
+	Interval:     time.Second,
+	Jitter:       250 * time.Millisecond,
+	Concurrency:  2,
+	Retries:      3,
+	RetryBackoff: 2 * time.Second,
+}
+
+// WithProfile applies every setting of the profile, ie. hnscraper.WithProfile(hnscraper.ProfileGentle).
+// Options after it can still override single settings.
+func WithProfile(p Profile) Option {
+	return func(s *Scraper) {
+		WithRateLimit(p.Interval)(s)
+		WithJitter(p.Jitter)(s)
+		WithMaxConcurrency(p.Concurrency)(s)
+		WithRetries(p.Retries, p.RetryBackoff)(s)
+	}
+}
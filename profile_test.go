@@ -0,0 +1,56 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithProfile(t *testing.T) {
+	s := NewScraper(WithProfile(ProfileGentle), WithRetries(0, 0))
+	if s.interval != 5*time.Second || s.jitter != time.Second || s.maxConcurrency != 1 {
+		t.Errorf("applied the gentle profile incorrectly: interval %v, jitter %v, concurrency %d",
+			s.interval, s.jitter, s.maxConcurrency)
+	}
+	if s.retries != 0 {
+		t.Error("a later option didn't override the profile's retries")
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	s := NewScraper(WithJitter(20 * time.Millisecond))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := s.wait(context.Background(), 0); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Error("waited ", elapsed, " for five jittered turns of at most 20ms")
+	}
+}
+
+func TestWithMaxConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		http.ServeFile(w, r, "testdata/item.html")
+	}, WithMaxConcurrency(2))
+
+	if _, err := s.ScrapeItems(context.Background(), []int{1, 2, 3, 4, 5, 6}, 6); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if peak := atomic.LoadInt32(&peak); peak > 2 {
+		t.Error("made ", peak, " requests at once instead of at most 2")
+	}
+}
@@ -0,0 +1,164 @@
+package hnscraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// A Baseline is the parsed result of every listing page in an archive directory, recorded by RecordBaseline
+// so a later RunRegression can tell whether parser changes altered what is scraped from the same HTML.
+type Baseline struct {
+	Dir   string         `json:"dir"`   // The archive directory, relative to the baseline file when possible
+	Pages []BaselinePage `json:"pages"` // Every listing page in the archive, oldest first
+}
+
+// A BaselinePage is one archived page and the result of parsing it.
+type BaselinePage struct {
+	Page string `json:"page"` // The name of the archived file within the archive directory
+	Want Page   `json:"want"` // What the page parsed to when the baseline was recorded
+}
+
+// A Difference is a field that parses differently from the baseline.
+type Difference struct {
+	File     string // The archived file that parses differently
+	ID       int    // The item ID of the post, or 0 when the page itself differs
+	Field    string // The name of the Post field that differs, "Post" for a post that was added or removed
+	Baseline string // The value in the baseline
+	Now      string // The value parsed now
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: item %d %s: %q, now %q", d.File, d.ID, d.Field, d.Baseline, d.Now)
+}
+
+// RecordBaseline parses every listing page in an archive directory and saves the result to baselinePath.
+// See Scraper.RecordBaseline.
+func RecordBaseline(archiveDir, baselinePath string) error {
+	return defaultScraper.RecordBaseline(archiveDir, baselinePath)
+}
+
+// RunRegression re-parses the archive a baseline was recorded from and reports every difference.
+// See Scraper.RunRegression.
+func RunRegression(baselinePath string) ([]Difference, error) {
+	return defaultScraper.RunRegression(baselinePath)
+}
+
+// RecordBaseline parses every listing page in an archive directory, as saved by WithArchiveDir,
+// and saves the result to baselinePath as JSON. Keep the archive and the baseline together,
+// ie. in testdata, so the baseline can be checked with RunRegression after changing the parser.
+func (s *Scraper) RecordBaseline(archiveDir, baselinePath string) error {
+	entries, err := ReadArchive(archiveDir)
+	if err != nil {
+		return err
+	}
+
+	baseline := Baseline{Dir: archiveDir}
+	// A relative directory lets the archive and baseline be moved together
+	if abs, err := filepath.Abs(archiveDir); err == nil {
+		if base, err := filepath.Abs(filepath.Dir(baselinePath)); err == nil {
+			if rel, err := filepath.Rel(base, abs); err == nil {
+				baseline.Dir = rel
+			}
+		}
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Page, "news") {
+			continue
+		}
+		page, err := s.ParseArchived(entry)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		baseline.Pages = append(baseline.Pages, BaselinePage{Page: filepath.Base(entry.Path), Want: page})
+	}
+
+	data, err := json.MarshalIndent(baseline, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(baselinePath, data, 0o644)
+}
+
+// RunRegression re-parses the archived pages of a baseline saved by RecordBaseline and compares them with it field
+// by field, returning every difference. No differences means the parser still reads the archive as it did.
+// A page that no longer parses at all fails the run.
+func (s *Scraper) RunRegression(baselinePath string) ([]Difference, error) {
+	var baseline Baseline
+	data, err := os.ReadFile(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	dir := baseline.Dir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(baselinePath), dir)
+	}
+
+	entries, err := ReadArchive(dir)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]ArchiveEntry, len(entries))
+	for _, entry := range entries {
+		byName[filepath.Base(entry.Path)] = entry
+	}
+
+	var diffs []Difference
+	for _, want := range baseline.Pages {
+		entry, ok := byName[want.Page]
+		if !ok {
+			return diffs, fmt.Errorf("%s: archived page is missing from %s", want.Page, dir)
+		}
+		got, err := s.ParseArchived(entry)
+		if err != nil {
+			return diffs, fmt.Errorf("%s: %w", want.Page, err)
+		}
+		diffs = append(diffs, comparePages(want.Page, want.Want, got)...)
+	}
+
+	return diffs, nil
+}
+
+// comparePages lists the differences between the posts of two parses of the same page, matching posts by Key.
+func comparePages(file string, want, got Page) []Difference {
+	var diffs []Difference
+
+	gotPosts := make(map[string]Post, len(got.Posts))
+	for _, post := range got.Posts {
+		gotPosts[post.Key()] = post
+	}
+	for _, wantPost := range want.Posts {
+		gotPost, ok := gotPosts[wantPost.Key()]
+		if !ok {
+			diffs = append(diffs, Difference{File: file, ID: wantPost.ID, Field: "Post", Baseline: wantPost.Title})
+			continue
+		}
+		delete(gotPosts, wantPost.Key())
+
+		wantValue, gotValue := reflect.ValueOf(wantPost), reflect.ValueOf(gotPost)
+		for _, field := range wantPost.Changed(gotPost) {
+			diffs = append(diffs, Difference{
+				File:     file,
+				ID:       wantPost.ID,
+				Field:    field,
+				Baseline: fmt.Sprint(wantValue.FieldByName(field).Interface()),
+				Now:      fmt.Sprint(gotValue.FieldByName(field).Interface()),
+			})
+		}
+	}
+	// Whatever is left wasn't in the baseline
+	for _, post := range got.Posts {
+		if _, ok := gotPosts[post.Key()]; ok {
+			diffs = append(diffs, Difference{File: file, ID: post.ID, Field: "Post", Now: post.Title})
+		}
+	}
+
+	return diffs
+}
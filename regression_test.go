@@ -0,0 +1,47 @@
+package hnscraper
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRunRegression(t *testing.T) {
+	dir := t.TempDir()
+	archiveDir := filepath.Join(dir, "archive")
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithArchiveDir(archiveDir))
+	if _, err := s.ScrapeMultPages(1, 2); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	baselinePath := filepath.Join(dir, "baseline.json")
+	if err := RecordBaseline(archiveDir, baselinePath); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	diffs, err := RunRegression(baselinePath)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(diffs) != 0 {
+		t.Error("unchanged parser reported differences ", diffs)
+	}
+
+	// A parser change that doubles every score
+	changed := NewScraper(WithFieldExtractor(FieldScore, func(row Row, post *Post) error {
+		err := DefaultExtractor(FieldScore)(row, post)
+		post.Score *= 2
+		return err
+	}))
+	diffs, err = changed.RunRegression(baselinePath)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(diffs) == 0 {
+		t.Fatal("changed parser reported no differences")
+	}
+	for _, diff := range diffs {
+		if diff.Field != "Score" || diff.ID == 0 {
+			t.Error("reported unexpected difference ", diff)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package hnscraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDisallowed is returned instead of requesting a path that robots.txt disallows, when WithRobots is used.
+var ErrDisallowed = errors.New("path is disallowed by robots.txt")
+
+// robotsAgent is the user agent name matched against robots.txt groups before falling back to "*".
+const robotsAgent = "hnscraper"
+
+// robotsRules are the rules from robots.txt that apply to this package.
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// parseRobots reads the rules for the group naming agent, or the "*" group if no group names it.
+// A robots.txt that can't be read to the end is an error, as the rules it would have gone on to list are unknown.
+func parseRobots(r io.Reader, agent string) (robotsRules, error) {
+	groups := map[string]*robotsRules{}
+	var current []*robotsRules
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		if key == "user-agent" {
+			// Consecutive user-agent lines share the rules that follow them
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if groups[name] == nil {
+				groups[name] = &robotsRules{}
+			}
+			current = append(current, groups[name])
+			continue
+		}
+		inAgents = false
+
+		for _, rules := range current {
+			switch key {
+			case "allow":
+				if value != "" {
+					rules.allow = append(rules.allow, value)
+				}
+			case "disallow":
+				if value != "" {
+					rules.disallow = append(rules.disallow, value)
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+					rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return robotsRules{}, err
+	}
+
+	if rules, ok := groups[agent]; ok {
+		return *rules, nil
+	} else if rules, ok := groups["*"]; ok {
+		return *rules, nil
+	}
+
+	return robotsRules{}, nil
+}
+
+// allowed reports whether the path (including its query) may be requested.
+// The longest matching rule wins, with allow rules winning ties.
+func (r robotsRules) allowed(path string) bool {
+	longestAllow, longestDisallow := -1, -1
+	for _, pattern := range r.allow {
+		if robotsMatch(pattern, path) && len(pattern) > longestAllow {
+			longestAllow = len(pattern)
+		}
+	}
+	for _, pattern := range r.disallow {
+		if robotsMatch(pattern, path) && len(pattern) > longestDisallow {
+			longestDisallow = len(pattern)
+		}
+	}
+
+	return longestAllow >= longestDisallow
+}
+
+// robotsMatch matches a robots.txt path pattern, which may use '*' wildcards and a trailing '$' anchor.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	// A trailing wildcard can absorb whatever is left
+	return !anchored || rest == "" || strings.HasSuffix(pattern, "*$")
+}
+
+// checkRobots loads robots.txt on first use and reports whether the URL may be requested.
+// Nothing is allowed while robots.txt can't be loaded.
+func (s *Scraper) checkRobots(ctx context.Context, rawURL string) error {
+	s.robotsMu.Lock()
+	defer s.robotsMu.Unlock()
+
+	if s.robots == nil {
+		rules, err := s.loadRobots(ctx)
+		if err != nil {
+			return err
+		}
+		s.robots = &rules
+		if rules.crawlDelay > 0 {
+			s.logf("robots.txt requests a crawl delay of %s", rules.crawlDelay)
+		}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if !s.robots.allowed(u.RequestURI()) {
+		return ErrDisallowed
+	}
+
+	return nil
+}
+
+func (s *Scraper) loadRobots(ctx context.Context) (robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"robots.txt", nil)
+	if err != nil {
+		return robotsRules{}, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	defer resp.Body.Close()
+
+	// A missing robots.txt allows everything
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return robotsRules{}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return robotsRules{}, errors.New("could not load robots.txt: " + resp.Status)
+	}
+
+	rules, err := parseRobots(&limitedReader{r: resp.Body, n: s.maxBodySize()}, robotsAgent)
+	if err != nil {
+		return robotsRules{}, fmt.Errorf("could not read robots.txt: %w", err)
+	}
+
+	return rules, nil
+}
+
+// crawlDelay is the delay robots.txt requests between requests, if robots.txt is respected and loaded.
+func (s *Scraper) crawlDelay() time.Duration {
+	s.robotsMu.Lock()
+	defer s.robotsMu.Unlock()
+
+	if s.robots == nil {
+		return 0
+	}
+
+	return s.robots.crawlDelay
+}
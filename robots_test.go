@@ -0,0 +1,105 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRobots = `User-Agent: *
+Disallow: /x?
+Disallow: /vote?
+Disallow: /*.json$
+Allow: /vote?how=up$
+Crawl-delay: 30
+
+User-agent: hnscraper
+User-agent: otherbot
+Disallow: /news
+Crawl-delay: 0.05
+`
+
+func TestParseRobots(t *testing.T) {
+	rules, err := parseRobots(strings.NewReader(testRobots), "somebot")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if rules.crawlDelay != 30*time.Second {
+		t.Error("parsed crawl delay of ", rules.crawlDelay, " instead of 30s")
+	}
+
+	cases := map[string]bool{
+		"/news?p=2":       true,
+		"/x?fnid=abc":     false,
+		"/vote?id=1":      false,
+		"/vote?how=up":    true,
+		"/items.json":     false,
+		"/items.json?x=1": true,
+	}
+	for path, want := range cases {
+		if rules.allowed(path) != want {
+			t.Error("allowed ", path, ": ", !want, " instead of ", want)
+		}
+	}
+
+	agentRules, _ := parseRobots(strings.NewReader(testRobots), robotsAgent)
+	if agentRules.allowed("/news?p=1") || agentRules.crawlDelay != 50*time.Millisecond {
+		t.Error("did not use the group for the named agent")
+	}
+}
+
+func TestWithRobots(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte(testRobots))
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}, WithRobots())
+
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrDisallowed) {
+		t.Error("returned ", err, " instead of ErrDisallowed")
+	}
+	if s.Requests() != 0 {
+		t.Error("requested a disallowed path")
+	}
+}
+
+func TestRobotsUnreadable(t *testing.T) {
+	// The line is too long to scan, so the rules after it are never seen
+	robots := "User-agent: *\n# " + strings.Repeat("x", 128<<10) + "\nDisallow: /\n"
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte(robots))
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}, WithRobots())
+
+	if _, err := s.ScrapePage(1); err == nil {
+		t.Error("scraped a page with a robots.txt that couldn't be read")
+	}
+	if s.Requests() != 0 {
+		t.Error("made a request without knowing what robots.txt allows")
+	}
+}
+
+func TestCrawlDelay(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nCrawl-delay: 0.05\n"))
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}, WithRobots())
+
+	start := time.Now()
+	if _, err := s.ScrapeMultPages(1, 3); err != nil {
+		t.Error("error: ", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Error("scraped 3 pages in ", elapsed, " despite a 50ms crawl delay")
+	}
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	specs := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"}
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Error("expected error for ", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// A Friday
+	from := time.Date(2021, 10, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2021, 10, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 10, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2021, 10, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 6 * * *", time.Date(2021, 10, 2, 6, 30, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 10, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 10, 1, 11, 0, 0, 0, time.UTC)},
+		// Either day field may match when both are restricted
+		{"0 0 15 * 0", time.Date(2021, 10, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec)
+		if err != nil {
+			t.Fatal("error: ", err)
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("%q: expected %v, got %v", test.spec, test.want, got)
+		}
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Error("expected no next time, got ", next)
+	}
+}
@@ -0,0 +1,193 @@
+// Package scheduler runs named scrape jobs on cron schedules.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/pipeline"
+)
+
+// A Job is a named task run on a cron schedule.
+type Job struct {
+	Name   string                          // Identifies the job in logs
+	Spec   string                          // When to run the job, as a cron expression. See ParseCron
+	Jitter time.Duration                   // The most each run is randomly delayed by, to avoid scraping at exactly the same moment as everyone else
+	Run    func(ctx context.Context) error // The work to do
+}
+
+// ScrapeJob returns a Job.Run function that scrapes pages startPage to endPage with the scraper
+// and writes every post to the sinks.
+func ScrapeJob(scraper *hnscraper.Scraper, startPage, endPage int, sinks ...pipeline.Sink) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return pipeline.New(pipeline.Pages(scraper, startPage, endPage)).To(sinks...).Run(ctx)
+	}
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	mu       sync.Mutex
+	running  bool
+}
+
+// A Scheduler runs jobs on their schedules. A run is skipped if the job's previous run hasn't finished yet.
+type Scheduler struct {
+	logger *log.Logger
+	now    func() time.Time // Overridden in tests
+
+	mu         sync.Mutex
+	entries    []*entry
+	cancelJobs context.CancelFunc // Cancels the running jobs, set by Run
+	wg         sync.WaitGroup     // Tracks running jobs
+
+	stop     chan struct{} // Closed by Shutdown to stop scheduling
+	stopOnce sync.Once
+	done     chan struct{} // Closed when Run returns
+}
+
+// New creates a Scheduler that reports job runs, failures, and skipped runs to the logger, which may be nil.
+func New(logger *log.Logger) *Scheduler {
+	return &Scheduler{logger: logger, now: time.Now, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// Add schedules a job. It must be called before Run.
+func (s *Scheduler) Add(job Job) error {
+	if job.Run == nil {
+		return errors.New("job has nothing to run: " + job.Name)
+	}
+	schedule, err := ParseCron(job.Spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, &entry{job: job, schedule: schedule})
+	return nil
+}
+
+// Run runs the scheduled jobs until ctx is done or Shutdown is called, then waits for running jobs to finish
+// before returning. Jobs are given a context derived from ctx, so they are asked to stop when ctx is done.
+// Run returns ctx's error, or nil after a Shutdown.
+func (s *Scheduler) Run(ctx context.Context) error {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	entries := append([]*entry(nil), s.entries...)
+	s.cancelJobs = cancel
+	s.mu.Unlock()
+
+	var loops sync.WaitGroup
+	for _, e := range entries {
+		loops.Add(1)
+		go func(e *entry) {
+			defer loops.Done()
+			s.loop(ctx, e)
+		}(e)
+	}
+	loops.Wait()
+	s.wg.Wait()
+
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops scheduling new runs and waits for running jobs to finish and Run to return.
+// If ctx is done first, the running jobs are cancelled and ctx's error is returned once they have stopped.
+// Shutdown must only be called after Run has been started.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if s.cancelJobs != nil {
+			s.cancelJobs()
+		}
+		s.mu.Unlock()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+// loop waits for each of the entry's scheduled times and starts a run.
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		now := s.now()
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			s.logf("job %s will never run again", e.job.Name)
+			return
+		}
+		wait := next.Sub(now)
+		if e.job.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(e.job.Jitter)))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.start(ctx, e)
+	}
+}
+
+// start runs the job in the background unless its previous run is still going.
+func (s *Scheduler) start(ctx context.Context, e *entry) {
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		s.logf("job %s skipped: previous run still in progress", e.job.Name)
+		return
+	}
+	e.running = true
+	e.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			e.mu.Lock()
+			e.running = false
+			e.mu.Unlock()
+		}()
+
+		started := time.Now()
+		if err := e.job.Run(ctx); err != nil {
+			s.logf("job %s failed after %s: %v", e.job.Name, time.Since(started), err)
+			return
+		}
+		s.logf("job %s finished in %s", e.job.Name, time.Since(started))
+	}()
+}
+
+func (s *Scheduler) logf(format string, args ...interface{}) {
+	if s.logger != nil {
+		s.logger.Printf("scheduler: "+format, args...)
+	}
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddInvalid(t *testing.T) {
+	s := New(nil)
+	if err := s.Add(Job{Name: "bad", Spec: "not cron", Run: func(context.Context) error { return nil }}); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+	if err := s.Add(Job{Name: "empty", Spec: "* * * * *"}); err == nil {
+		t.Error("expected error for job without Run")
+	}
+}
+
+func TestStartSkipsOverlappingRuns(t *testing.T) {
+	s := New(nil)
+	if err := s.Add(Job{Name: "slow", Spec: "* * * * *", Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	var runs int32
+	release := make(chan struct{})
+	e := s.entries[0]
+	e.job.Run = func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}
+
+	s.start(context.Background(), e)
+	s.start(context.Background(), e)
+	close(release)
+	s.wg.Wait()
+
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Error("expected 1 run, got ", got)
+	}
+
+	// Once finished, the job can run again
+	s.start(context.Background(), e)
+	s.wg.Wait()
+	if got := atomic.LoadInt32(&runs); got != 2 {
+		t.Error("expected 2 runs, got ", got)
+	}
+}
+
+func TestRunFiresJobs(t *testing.T) {
+	s := New(nil)
+	// Pretend it's always just before the minute so the job fires almost immediately
+	s.now = func() time.Time {
+		return time.Now().Truncate(time.Minute).Add(time.Minute - 10*time.Millisecond)
+	}
+
+	var runs int32
+	err := s.Add(Job{Name: "tick", Spec: "* * * * *", Run: func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); err != context.DeadlineExceeded {
+		t.Error("expected deadline exceeded, got ", err)
+	}
+	if atomic.LoadInt32(&runs) == 0 {
+		t.Error("expected the job to run")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := New(nil)
+	s.now = func() time.Time {
+		return time.Now().Truncate(time.Minute).Add(time.Minute - 10*time.Millisecond)
+	}
+
+	started := make(chan struct{})
+	var finished int32
+	err := s.Add(Job{Name: "slow", Spec: "* * * * *", Run: func(ctx context.Context) error {
+		if atomic.AddInt32(&finished, 0) == 0 {
+			close(started)
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&finished, 1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- s.Run(context.Background()) }()
+	<-started
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Error("error: ", err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Error("returned before the running job finished")
+	}
+	if err := <-result; err != nil {
+		t.Error("Run returned ", err, " after a shutdown")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	s := New(nil)
+	s.now = func() time.Time {
+		return time.Now().Truncate(time.Minute).Add(time.Minute - 10*time.Millisecond)
+	}
+
+	started := make(chan struct{})
+	err := s.Add(Job{Name: "stuck", Spec: "* * * * *", Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	go s.Run(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Error("returned ", err, " instead of the shutdown deadline")
+	}
+}
@@ -0,0 +1,239 @@
+package hnscraper
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A Scraper scrapes HackerNews according to its configured options.
+// Create one with NewScraper; the package-level functions use a Scraper with the default options.
+type Scraper struct {
+	baseURL      string         // The root of the site being scraped, overridden in tests
+	location     *time.Location // The time zone every parsed timestamp is converted to
+	rawTitles    bool           // Whether to skip normalizing post titles
+	skipPinned   bool           // Whether to leave pinned and promoted posts out of pages
+	client       *http.Client   // The client every request is made with
+	ownTransport bool           // Whether client is a copy whose transport the Scraper may tune
+	logger       *log.Logger    // Where operational events are reported, nil to discard them
+	tracer       Tracer         // Traces the stages of every scrape, nil to disable
+	session      string         // The login cookie requests are made with, empty to stay logged out
+	sessionPool  *SessionPool   // The logins requests rotate between when session is empty, nil to disable
+	breaker      *breaker       // Stops requests after repeated failures, nil to disable
+	staleCache   PageCache      // Serves the last snapshot of pages while HackerNews is down, nil to disable
+	coalescer    *coalescer     // Shares scrapes of the same page between callers, nil to disable
+
+	extractors       map[Field]FieldFunc // Custom extractors replacing the built-in ones, by field
+	fields           map[Field]bool      // The fields extracted from rows, nil for all of them
+	commentSizeLimit int                 // The most bytes StreamComments buffers at once, 0 for the default
+	maxBody          int64               // The most bytes a response may have, 0 for the default
+
+	requestTimeout   time.Duration // How long a single request may take, 0 for no limit
+	operationTimeout time.Duration // How long a public call may take in total, 0 for no limit
+	retries          int           // How many times a failed request is retried
+	retryBackoff     time.Duration // How long to wait before the first retry, doubling after
+
+	maxRequests int           // The most requests the Scraper may make, 0 for no limit
+	mu          sync.Mutex    // Guards requests, lastTurn, and lastPost
+	requests    int           // How many requests the Scraper has made
+	lastTurn    time.Time     // When the most recent request was allowed to start
+	interval    time.Duration // The minimum time between requests
+	jitter      time.Duration // The most random delay added to interval
+
+	postInterval time.Duration // The minimum time between submissions and replies, 0 for the default
+	lastPost     time.Time     // When the most recent submission or reply was made
+
+	maxConcurrency int // The most requests ScrapeItems makes at once, 0 for no limit
+
+	archiveDir  string                               // Where the raw HTML of every page is saved, empty to disable
+	archiveFunc func(name string, body []byte) error // Receives the raw HTML of every page instead of archiveDir
+	archiveGzip bool                                 // Whether pages saved to archiveDir are gzipped
+	debugDir    string                               // Where pages that fail to parse are saved, empty to disable
+
+	respectRobots bool         // Whether to follow robots.txt
+	robotsMu      sync.Mutex   // Guards robots
+	robots        *robotsRules // The rules from robots.txt, nil until loaded
+}
+
+// An Option configures a Scraper.
+type Option func(*Scraper)
+
+// NewScraper creates a Scraper, applying the options in order.
+func NewScraper(opts ...Option) *Scraper {
+	s := &Scraper{baseURL: hackernewsURL, location: time.Local, client: &http.Client{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithUTC normalizes every parsed timestamp to UTC instead of the local time zone.
+func WithUTC() Option {
+	return func(s *Scraper) {
+		s.location = time.UTC
+	}
+}
+
+// WithRawTitles leaves Post.Title exactly as it appeared on the page instead of normalizing it.
+func WithRawTitles() Option {
+	return func(s *Scraper) {
+		s.rawTitles = true
+	}
+}
+
+// WithSkipPinned leaves pinned and promoted posts out of scraped pages instead of only flagging them
+// with Post.Pinned and Post.Promoted, so the ranks of the remaining posts are continuous.
+func WithSkipPinned() Option {
+	return func(s *Scraper) {
+		s.skipPinned = true
+	}
+}
+
+// WithHTTPClient makes every request with the given client, ie. to route requests through a proxy.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Scraper) {
+		s.client = client
+		s.ownTransport = false
+	}
+}
+
+// WithLogger reports operational events, such as circuit breaker transitions, to the logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Scraper) {
+		s.logger = logger
+	}
+}
+
+// WithCircuitBreaker stops making requests for the cooldown period once threshold consecutive requests have failed,
+// returning ErrCircuitOpen instead. After the cooldown a single request is let through to test whether HackerNews has recovered.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Scraper) {
+		s.breaker = &breaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			onChange: func(from, to BreakerState) {
+				s.logf("circuit breaker %s -> %s", from, to)
+			},
+		}
+	}
+}
+
+// WithMaxRequests limits the Scraper to making at most n requests over its lifetime,
+// so a runaway loop can't flood HackerNews. Once the budget is spent every scrape fails with ErrBudgetExceeded.
+func WithMaxRequests(n int) Option {
+	return func(s *Scraper) {
+		s.maxRequests = n
+	}
+}
+
+// WithRateLimit spaces requests at least interval apart.
+// The limit is shared by everything the Scraper does, including concurrent requests from ScrapeItems.
+func WithRateLimit(interval time.Duration) Option {
+	return func(s *Scraper) {
+		s.interval = interval
+	}
+}
+
+// WithJitter adds a random delay of up to d to the interval before each request,
+// so a scheduled scraper doesn't hit HackerNews in a perfectly regular rhythm.
+func WithJitter(d time.Duration) Option {
+	return func(s *Scraper) {
+		s.jitter = d
+	}
+}
+
+// WithMaxConcurrency caps how many requests calls such as ScrapeItems make at once, whatever number of workers
+// they are asked for. A value of 0 leaves the number of workers up to the caller.
+func WithMaxConcurrency(n int) Option {
+	return func(s *Scraper) {
+		s.maxConcurrency = n
+	}
+}
+
+// WithRobots fetches robots.txt before the first request and follows it from then on:
+// disallowed paths fail with ErrDisallowed, and requests are spaced out by its crawl delay.
+func WithRobots() Option {
+	return func(s *Scraper) {
+		s.respectRobots = true
+	}
+}
+
+// WithArchiveDir saves the raw HTML of every page the Scraper retrieves to the directory,
+// so it can be parsed again later with ReplayArchive. Pages retrieved with a session, see WithSession and
+// WithSessionPool, are never archived, as they hold the account's auth tokens for voting and other actions.
+func WithArchiveDir(dir string) Option {
+	return func(s *Scraper) {
+		s.archiveDir = dir
+	}
+}
+
+// WithArchiveGzip gzips the pages saved by WithArchiveDir, adding a ".gz" suffix.
+// ReadArchive and ReplayArchive read compressed pages transparently.
+func WithArchiveGzip() Option {
+	return func(s *Scraper) {
+		s.archiveGzip = true
+	}
+}
+
+// WithArchiveFunc hands the raw HTML of every page the Scraper retrieves to fn instead of saving it to a directory,
+// ie. to upload it to object storage. The name is the file name WithArchiveDir would have used,
+// so downloading the files into a directory makes it readable by ReadArchive.
+// Like WithArchiveDir, pages retrieved with a session are never handed to fn. An error from fn fails the request.
+func WithArchiveFunc(fn func(name string, body []byte) error) Option {
+	return func(s *Scraper) {
+		s.archiveFunc = fn
+	}
+}
+
+// WithCommentSizeLimit caps how many bytes StreamComments buffers for a single comment's text or piece of markup,
+// failing with ErrCommentTooLarge beyond it. The default is 1 MiB.
+func WithCommentSizeLimit(n int) Option {
+	return func(s *Scraper) {
+		s.commentSizeLimit = n
+	}
+}
+
+// WithMaxBodySize fails any response larger than n bytes with ErrBodyTooLarge instead of reading it all,
+// protecting the process from pathological responses. The default is 32 MiB.
+func WithMaxBodySize(n int64) Option {
+	return func(s *Scraper) {
+		s.maxBody = n
+	}
+}
+
+// WithRequestTimeout fails any single request that takes longer than d, including reading its body,
+// so one slow page fails fast instead of holding up the whole scrape. Combine it with WithRetries to try again.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(s *Scraper) {
+		s.requestTimeout = d
+	}
+}
+
+// WithOperationTimeout bounds each call, such as ScrapeMultPages or ScrapeItems, to d in total,
+// including every request, retry, and rate limit wait it makes.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(s *Scraper) {
+		s.operationTimeout = d
+	}
+}
+
+// WithRetries retries a failed request up to n more times, waiting backoff before the first retry
+// and doubling the wait each time after. Only timeouts, connection failures, and server errors are retried.
+// Every retry counts against WithMaxRequests and waits for the rate limit like any other request.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(s *Scraper) {
+		s.retries = n
+		s.retryBackoff = backoff
+	}
+}
+
+// logf reports an operational event if the Scraper has a logger.
+func (s *Scraper) logf(format string, args ...interface{}) {
+	if s.logger != nil {
+		s.logger.Printf("hnscraper: "+format, args...)
+	}
+}
+
+var defaultScraper = NewScraper()
@@ -0,0 +1,78 @@
+package hnscraper
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// The second-chance heuristic only considers the front page, where re-upped posts are placed
+const (
+	secondChanceMaxRank   = 30             // The lowest rank a re-upped post is looked for at
+	secondChanceNeighbors = 5              // How many posts either side a post's age is compared against
+	secondChanceFactor    = 4              // How many times older than its neighbors a re-upped post is
+	secondChanceMinAge    = 12 * time.Hour // The youngest a re-upped post can be, so fresh pages aren't flagged
+)
+
+// markSecondChance flags the highly ranked posts that are far older than the posts around them,
+// which only happens when HN's second-chance pool puts an old post back on the front page.
+func markSecondChance(posts []Post, retrieved time.Time) {
+	for i := range posts {
+		post := &posts[i]
+		if post.Rank < 1 || post.Rank > secondChanceMaxRank || post.Pinned || post.Promoted {
+			continue
+		}
+
+		var ages []time.Duration
+		for j := i - secondChanceNeighbors; j <= i+secondChanceNeighbors; j++ {
+			if j < 0 || j >= len(posts) || j == i || posts[j].Pinned || posts[j].Promoted {
+				continue
+			}
+			ages = append(ages, retrieved.Sub(posts[j].TimePosted))
+		}
+		if len(ages) == 0 {
+			continue
+		}
+		sort.Slice(ages, func(a, b int) bool { return ages[a] < ages[b] })
+		median := ages[len(ages)/2]
+
+		age := retrieved.Sub(post.TimePosted)
+		post.SecondChance = age >= secondChanceMinAge && age > secondChanceFactor*median
+	}
+}
+
+// ConfirmSecondChance checks a post from a listing against its item page to tell whether it was re-upped
+// by HN's second-chance pool. Re-upping adjusts a post's timestamp, which shows up as the listing and the item page
+// disagreeing on when the post was submitted, or as comments that predate the post.
+// The check costs a request per post, so it is best saved for posts the heuristic behind Post.SecondChance flagged.
+func (s *Scraper) ConfirmSecondChance(ctx context.Context, post Post) (bool, error) {
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	item, err := s.scrapeItem(ctx, post.ID)
+	if err != nil {
+		return false, err
+	}
+
+	diff := post.TimePosted.Sub(item.Post.TimePosted)
+	if diff < 0 {
+		diff = -diff
+	}
+	// Relative ages are only accurate to the minute, or the hour for older posts
+	if diff > time.Hour {
+		return true, nil
+	}
+
+	return commentsPredate(item.Comments, item.Post.TimePosted), nil
+}
+
+// commentsPredate reports whether any comment in the tree was posted before the time.
+func commentsPredate(comments []Comment, t time.Time) bool {
+	for _, comment := range comments {
+		if comment.TimePosted.Before(t) || commentsPredate(comment.Replies, t) {
+			return true
+		}
+	}
+
+	return false
+}
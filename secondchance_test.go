@@ -0,0 +1,70 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkSecondChance(t *testing.T) {
+	retrieved := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	var posts []Post
+	for i := 1; i <= 10; i++ {
+		posts = append(posts, Post{ID: i, Rank: i, TimePosted: retrieved.Add(-time.Duration(i) * time.Hour)})
+	}
+	// A day and a half old among posts a few hours old
+	posts[2].TimePosted = retrieved.Add(-36 * time.Hour)
+	// Job ads are often old, but aren't re-upped
+	posts[6].TimePosted = retrieved.Add(-72 * time.Hour)
+	posts[6].Promoted = true
+
+	markSecondChance(posts, retrieved)
+	for _, post := range posts {
+		if post.SecondChance != (post.ID == 3) {
+			t.Error("flagged post ", post.ID, " as second chance: ", post.SecondChance)
+		}
+	}
+
+	// A fresh page has no old enough posts to flag
+	fresh := []Post{
+		{ID: 1, Rank: 1, TimePosted: retrieved.Add(-2 * time.Hour)},
+		{ID: 2, Rank: 2, TimePosted: retrieved.Add(-10 * time.Minute)},
+		{ID: 3, Rank: 3, TimePosted: retrieved.Add(-15 * time.Minute)},
+	}
+	markSecondChance(fresh, retrieved)
+	if fresh[0].SecondChance {
+		t.Error("flagged a two hour old post as second chance")
+	}
+}
+
+func TestConfirmSecondChance(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/item.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	body := string(fixture)
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}, WithUTC())
+
+	post := Post{ID: 28719320, TimePosted: time.Date(2021, 10, 1, 15, 0, 36, 0, time.UTC)}
+	if again, err := s.ConfirmSecondChance(context.Background(), post); err != nil || again {
+		t.Error("confirmed an unchanged post as second chance: ", again, err)
+	}
+
+	post.TimePosted = post.TimePosted.Add(20 * time.Hour)
+	if again, err := s.ConfirmSecondChance(context.Background(), post); err != nil || !again {
+		t.Error("missed a post whose listing time moved: ", again, err)
+	}
+
+	// Move the post after its first comment, as re-upping does on the item page
+	body = strings.Replace(string(fixture), "2021-10-01T15:00:36", "2021-10-01T18:00:36", 1)
+	post.TimePosted = time.Date(2021, 10, 1, 18, 0, 36, 0, time.UTC)
+	if again, err := s.ConfirmSecondChance(context.Background(), post); err != nil || !again {
+		t.Error("missed a post with comments older than itself: ", again, err)
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+// A Feed is a named, saved filter served as an Atom feed.
+// A post must match every criterion that is set.
+type Feed struct {
+	Name     string   // The name in the feed's URL, /feeds/{name}
+	Title    string   // The title shown in feed readers, the name if empty
+	MinScore int      // The lowest score a post can have
+	Keywords []string // Words of which the title must contain at least one, ignoring case
+	Domains  []string // Domains of which the post must link to at least one, including subdomains
+	Limit    int      // The most posts in the feed, newest first, 0 for 50
+}
+
+// filter combines the feed's criteria.
+func (f Feed) filter() filter.Filter {
+	filters := []filter.Filter{filter.ByMinScore(f.MinScore)}
+
+	if len(f.Keywords) > 0 {
+		filters = append(filters, func(p hnscraper.Post) bool {
+			title := strings.ToLower(p.Title)
+			for _, keyword := range f.Keywords {
+				if strings.Contains(title, strings.ToLower(keyword)) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	if len(f.Domains) > 0 {
+		var domains []filter.Filter
+		for _, domain := range f.Domains {
+			domains = append(domains, filter.ByDomain(domain))
+		}
+		filters = append(filters, filter.Or(domains...))
+	}
+
+	return filter.And(filters...)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Links   []atomLink `xml:"link"`
+	Summary string     `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// write renders the posts matching the feed as an Atom document.
+func (f Feed) write(w io.Writer, self string, posts []hnscraper.Post) error {
+	limit := f.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	matching := hnscraper.Posts(filter.Posts(posts, f.filter())).SortByTime()
+	if len(matching) > limit {
+		matching = matching[:limit]
+	}
+
+	title := f.Title
+	if title == "" {
+		title = f.Name
+	}
+	doc := atomFeed{
+		Title: title,
+		ID:    self,
+		Links: []atomLink{{Href: self, Rel: "self"}},
+	}
+
+	updated := time.Time{}
+	for _, post := range matching {
+		if post.TimePosted.After(updated) {
+			updated = post.TimePosted
+		}
+		// Posts stored without a discussion link, ie. imported ones, are linked to HackerNews itself
+		discussion := post.CommentsURL
+		if discussion == "" {
+			discussion = "https://news.ycombinator.com/item?id=" + strconv.Itoa(post.ID)
+		}
+		link := post.URL
+		if post.Domain() == "" {
+			link = discussion
+		}
+
+		doc.Entries = append(doc.Entries, atomEntry{
+			Title:   post.Title,
+			ID:      discussion,
+			Updated: post.TimePosted.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: post.By},
+			Links:   []atomLink{{Href: link}, {Href: discussion, Rel: "replies"}},
+			Summary: fmt.Sprintf("%d points, %d comments", post.Score, post.NumComments),
+		})
+	}
+	doc.Updated = updated.UTC().Format(time.RFC3339)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(doc)
+}
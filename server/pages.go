@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// ScrapePages serves listing pages scraped live with the scraper at /pages/{n}.
+// If the scraper has a stale cache, see hnscraper.WithStaleCache, the last snapshot is served while HackerNews is down,
+// with the page's Stale flag set and its age in seconds in the Age header.
+// Configure the scraper with hnscraper.WithCoalescing so a burst of clients asking for a page shares one request.
+func (s *Server) ScrapePages(scraper *hnscraper.Scraper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scraper = scraper
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	scraper := s.scraper
+	s.mu.RUnlock()
+	if scraper == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	value := strings.TrimPrefix(r.URL.Path, "/pages/")
+	num, err := strconv.Atoi(value)
+	if err != nil || num < 1 {
+		http.Error(w, (&paramError{"page", value}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The scrape is abandoned if the client goes away, but its error may name upstream URLs, so only the log sees it
+	page, err := scraper.ScrapePageContext(r.Context(), num)
+	if err != nil {
+		s.logf("scraping page %d: %v", num, err)
+		http.Error(w, "could not scrape page", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if page.Stale {
+		age := s.now().Sub(page.Retrieved)
+		if age < 0 {
+			age = 0
+		}
+		w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	}
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		s.logf("writing page %d: %v", num, err)
+	}
+}
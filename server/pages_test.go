@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/hnscrapertest"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+func TestPages(t *testing.T) {
+	site := hnscrapertest.NewServer(
+		hnscrapertest.Story{ID: 1, Title: "First", URL: "https://example.com/1", By: "alice", Score: 10, Posted: now},
+		hnscrapertest.Story{ID: 2, Title: "Second", URL: "https://example.com/2", By: "bob", Score: 5, Posted: now},
+	)
+	defer site.Close()
+
+	s := newTestServer(t)
+	var logs bytes.Buffer
+	s.SetLogger(log.New(&logs, "", 0))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pages/1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Error("responded with ", rec.Code, " before pages were enabled")
+	}
+
+	cache := store.NewMemory()
+	s.ScrapePages(hnscraper.NewScraper(hnscraper.WithHTTPClient(site.Client()), hnscraper.WithStaleCache(cache)))
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pages/1", nil))
+	var page hnscraper.Page
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal("error: ", err, rec.Body.String())
+	}
+	if rec.Code != http.StatusOK || len(page.Posts) != 2 || page.Stale || rec.Header().Get("Age") != "" {
+		t.Fatal("responded with ", rec.Code, " and ", len(page.Posts), " posts, stale ", page.Stale)
+	}
+
+	// HackerNews goes down, and the snapshot from a minute ago is served instead
+	site.Throttle(1)
+	s.now = func() time.Time { return page.Retrieved.Add(time.Minute) }
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pages/1", nil))
+	var stale hnscraper.Page
+	if err := json.Unmarshal(rec.Body.Bytes(), &stale); err != nil {
+		t.Fatal("error: ", err, rec.Body.String())
+	}
+	if rec.Code != http.StatusOK || !stale.Stale || len(stale.Posts) != 2 || rec.Header().Get("Age") != "60" {
+		t.Error("responded with ", rec.Code, ", stale ", stale.Stale, ", and age ", rec.Header().Get("Age"))
+	}
+
+	site.Throttle(1)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pages/2", nil))
+	if rec.Code != http.StatusBadGateway || strings.Contains(rec.Body.String(), "http") {
+		t.Error("responded with ", rec.Code, " and ", rec.Body.String(), " for an uncached page while down")
+	}
+	if !strings.Contains(logs.String(), "scraping page 2") {
+		t.Error("did not log the scrape error: ", logs.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pages/zero", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Error("responded with ", rec.Code, " for an invalid page")
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+const (
+	defaultLimit = 30
+	maxLimit     = 500
+)
+
+// postsResponse is the body served by /posts.
+type postsResponse struct {
+	Posts      []hnscraper.Post `json:"posts"`
+	NextOffset int              `json:"next_offset,omitempty"` // The offset of the next page, 0 on the last page
+}
+
+// parseQuery reads the /posts query parameters into a store query.
+func (s *Server) parseQuery(r *http.Request) (store.Query, error) {
+	params := r.URL.Query()
+	q := store.Query{
+		Domain: params.Get("domain"),
+		Author: params.Get("author"),
+		Type:   params.Get("type"),
+		Limit:  defaultLimit,
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"min_score", &q.MinScore},
+		{"min_comments", &q.MinComments},
+		{"limit", &q.Limit},
+		{"offset", &q.Offset},
+	}
+	for _, param := range ints {
+		value := params.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return q, &paramError{param.name, value}
+		}
+		*param.dst = n
+	}
+	if q.Limit == 0 {
+		return q, &paramError{"limit", params.Get("limit")}
+	}
+	if q.Limit > maxLimit {
+		q.Limit = maxLimit
+	}
+
+	if value := params.Get("max_age"); value != "" {
+		age, err := time.ParseDuration(value)
+		if err != nil || age <= 0 {
+			return q, &paramError{"max_age", value}
+		}
+		q.Since = s.now().Add(-age)
+	}
+
+	switch q.Type {
+	case "", hnscraper.TypeStory, hnscraper.TypeAsk, hnscraper.TypeShow, hnscraper.TypeLaunch, hnscraper.TypeJob:
+	default:
+		return q, &paramError{"type", q.Type}
+	}
+
+	return q, nil
+}
+
+type paramError struct {
+	name, value string
+}
+
+func (e *paramError) Error() string {
+	return "invalid " + e.name + ": " + strconv.Quote(e.value)
+}
+
+func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := s.parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Ask for one extra post to find out whether there's another page
+	limit := q.Limit
+	q.Limit++
+	posts, err := s.store.Query(q)
+	if err != nil {
+		s.logf("querying posts: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	resp := postsResponse{Posts: posts}
+	if len(posts) > limit {
+		resp.Posts = posts[:limit]
+		resp.NextOffset = q.Offset + limit
+	}
+	if resp.Posts == nil {
+		resp.Posts = []hnscraper.Post{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logf("writing posts: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPosts(t *testing.T) {
+	s := newTestServer(t)
+	s.now = func() time.Time { return now }
+
+	tests := []struct {
+		query      string
+		want       []int
+		nextOffset int
+	}{
+		{"", []int{3, 2, 4, 1}, 0},
+		{"?min_score=100", []int{2, 1}, 0},
+		{"?max_age=90m", []int{3, 2}, 0},
+		{"?domain=github.com", []int{1}, 0},
+		{"?author=dave&type=ask", []int{4}, 0},
+		{"?limit=2", []int{3, 2}, 2},
+		{"?limit=2&offset=2", []int{4, 1}, 0},
+		{"?offset=10", []int{}, 0},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts"+test.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Error(test.query, ": responded with ", rec.Code)
+			continue
+		}
+
+		var resp postsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal("error: ", err)
+		}
+		var ids []int
+		for _, post := range resp.Posts {
+			ids = append(ids, post.ID)
+		}
+		if len(ids) != len(test.want) || resp.NextOffset != test.nextOffset {
+			t.Error(test.query, ": returned ", ids, " and next offset ", resp.NextOffset, " instead of ", test.want, " and ", test.nextOffset)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.want[i] {
+				t.Error(test.query, ": returned ", ids, " instead of ", test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPostsBadParams(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, query := range []string{"?min_score=lots", "?limit=-1", "?limit=0", "?max_age=yesterday", "?type=poll"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Error(query, ": responded with ", rec.Code, " instead of 400")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package server serves the contents of a store over HTTP.
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+// A Server is an http.Handler serving the posts in a store.
+// Routes:
+//
+//	GET /posts         The stored posts as JSON, newest first. Filtered by the min_score, min_comments,
+//	                   max_age (ie. "6h"), domain, author, and type query parameters, and paged with limit and offset.
+//	                   The limit defaults to 30 posts and is capped at 500
+//	GET /feeds/{name}  An Atom feed of the posts matching the named Feed
+//	GET /pages/{n}     Listing page n as JSON, scraped live, when enabled by ScrapePages
+type Server struct {
+	store store.Store
+	mux   *http.ServeMux
+	now   func() time.Time // Overridden in tests
+
+	mu       sync.RWMutex
+	feeds    map[string]Feed
+	scraper  *hnscraper.Scraper // Scrapes /pages, nil until ScrapePages is called
+	http     *http.Server       // Serves ListenAndServe, created by New so Shutdown always has it to stop
+	shutdown bool               // Whether Shutdown was called
+	logger   *log.Logger        // Where errors kept from clients are reported, nil to discard them
+}
+
+// New creates a Server reading from the store.
+func New(st store.Store) *Server {
+	s := &Server{store: st, mux: http.NewServeMux(), now: time.Now, feeds: make(map[string]Feed)}
+	s.mux.HandleFunc("/posts", s.handlePosts)
+	s.mux.HandleFunc("/feeds/", s.handleFeed)
+	s.mux.HandleFunc("/pages/", s.handlePage)
+	s.http = &http.Server{Handler: s}
+
+	return s
+}
+
+// ServeHTTP serves a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves on the TCP address until Shutdown is called, returning http.ErrServerClosed after a shutdown,
+// including one that came before it was called.
+func (s *Server) ListenAndServe(addr string) error {
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	s.http.Addr = addr
+	s.mu.Unlock()
+
+	return s.http.ListenAndServe()
+}
+
+// Shutdown stops a server started by ListenAndServe from accepting new connections,
+// and waits for in-flight requests to finish or ctx to be done, whichever comes first.
+// A server shut down before it was started never starts.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shutdown = true
+	s.mu.Unlock()
+
+	return s.http.Shutdown(ctx)
+}
+
+// SetLogger reports errors that clients are only given a generic message for, ie. failed scrapes and store reads,
+// to the logger.
+func (s *Server) SetLogger(logger *log.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logger = logger
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	s.mu.RLock()
+	logger := s.logger
+	s.mu.RUnlock()
+	if logger != nil {
+		logger.Printf("server: "+format, args...)
+	}
+}
+
+// AddFeed makes the feed available at /feeds/{name}, replacing any feed with the same name.
+func (s *Server) AddFeed(feed Feed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feeds[feed.Name] = feed
+}
+
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/feeds/"), ".atom")
+	s.mu.RLock()
+	feed, ok := s.feeds[name]
+	s.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	posts, err := s.store.Posts()
+	if err != nil {
+		s.logf("reading posts for feed %s: %v", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	self := "http://" + r.Host + r.URL.Path
+	if r.TLS != nil {
+		self = "https://" + r.Host + r.URL.Path
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if err := feed.write(w, self, posts); err != nil {
+		s.logf("writing feed %s: %v", name, err)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/store"
+)
+
+var now = time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func newTestServer(t *testing.T) *Server {
+	st := store.NewMemory()
+	err := st.PutPage(hnscraper.Page{Num: 1, Retrieved: now, Posts: []hnscraper.Post{
+		{ID: 1, Title: "Rust in production", Score: 200, By: "alice", URL: "https://github.com/a", TimePosted: now.Add(-3 * time.Hour)},
+		{ID: 2, Title: "Go generics", Score: 150, By: "bob", URL: "https://go.dev/blog", TimePosted: now.Add(-time.Hour)},
+		{ID: 3, Title: "Rusty old cars", Score: 5, By: "carol", URL: "https://example.com", TimePosted: now},
+		{ID: 4, Title: "Ask HN: Rust or Go?", Score: 90, By: "dave", URL: "item?id=4", TimePosted: now.Add(-2 * time.Hour),
+			CommentsURL: "https://hn.example/item?id=4"},
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	return New(st)
+}
+
+func TestFeed(t *testing.T) {
+	s := newTestServer(t)
+	s.AddFeed(Feed{Name: "langs", Title: "Languages", MinScore: 50, Keywords: []string{"rust", "GO"}})
+	s.AddFeed(Feed{Name: "github", Domains: []string{"github.com"}})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feeds/langs", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatal("responded with ", rec.Code, " and content type ", rec.Header().Get("Content-Type"))
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if feed.Title != "Languages" || len(feed.Entries) != 3 {
+		t.Fatal("served feed ", feed.Title, " with ", len(feed.Entries), " entries instead of 3")
+	}
+	if feed.Entries[0].Title != "Go generics" || feed.Updated != "2021-10-16T11:00:00Z" {
+		t.Error("did not order entries newest first")
+	}
+	if feed.Entries[1].Links[0].Href != "https://hn.example/item?id=4" || feed.Entries[1].ID != "https://hn.example/item?id=4" {
+		t.Error("did not link self post to its discussion")
+	}
+	if feed.Entries[0].ID != "https://news.ycombinator.com/item?id=2" {
+		t.Error("linked post without a discussion link to ", feed.Entries[0].ID)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feeds/github.atom", nil))
+	var github atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &github); err != nil || len(github.Entries) != 1 {
+		t.Error("served ", len(github.Entries), " entries for github.com instead of 1")
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feeds/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Error("responded with ", rec.Code, " for a missing feed")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := newTestServer(t)
+	result := make(chan error, 1)
+	go func() { result <- s.ListenAndServe("127.0.0.1:0") }()
+
+	// However the shutdown and start race, the server stops
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Error("error: ", err)
+	}
+	if err := <-result; err != http.ErrServerClosed {
+		t.Error("ListenAndServe returned ", err, " after a shutdown")
+	}
+
+	s = newTestServer(t)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Error("shutting down an unstarted server returned ", err)
+	}
+	if err := s.ListenAndServe("127.0.0.1:0"); err != http.ErrServerClosed {
+		t.Error("ListenAndServe returned ", err, " after an earlier shutdown")
+	}
+}
+
+// brokenStore fails every read with an error naming its file.
+type brokenStore struct {
+	store.Store
+}
+
+func (brokenStore) Posts() ([]hnscraper.Post, error) {
+	return nil, errors.New("read /var/lib/hnscraper/posts.db: input/output error")
+}
+
+func (brokenStore) Query(q store.Query) ([]hnscraper.Post, error) {
+	return nil, errors.New("read /var/lib/hnscraper/posts.db: input/output error")
+}
+
+func TestStoreError(t *testing.T) {
+	s := New(brokenStore{})
+	s.AddFeed(Feed{Name: "all"})
+	var logs bytes.Buffer
+	s.SetLogger(log.New(&logs, "", 0))
+
+	for _, path := range []string{"/posts", "/feeds/all"} {
+		logs.Reset()
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "posts.db") {
+			t.Error(path, " responded with ", rec.Code, " and ", rec.Body.String())
+		}
+		if !strings.Contains(logs.String(), "posts.db") {
+			t.Error(path, " did not log the store error: ", logs.String())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// ErrNoprocrast is returned when HackerNews refuses to show a page to a logged-in account
+// because its noprocrast setting is in effect.
+var ErrNoprocrast = errors.New("hackernews is blocking the account for noprocrast")
+
+// sessionCookie is the name of the cookie HackerNews keeps the login in
+const sessionCookie = "user"
+
+var (
+	hideLinkExpr = xpath.MustCompile("/a[starts-with(@href, 'hide?')]")
+	bodyExpr     = xpath.MustCompile("//body")
+)
+
+// WithSession makes every request as a logged-in account, using the value of the "user" cookie
+// from a browser that is logged in to HackerNews. Listings then flag the posts the account has hidden
+// and carry the auth token each post's hide link needs, see Post.Hidden and Post.HideAuth.
+// Keep the cookie secret: it grants full access to the account.
+func WithSession(cookie string) Option {
+	return func(s *Scraper) {
+		s.session = cookie
+	}
+}
+
+// getHideLink reads the post's hide link, which logged-in accounts see with an auth token,
+// and which becomes an "un-hide" link on posts the account has hidden.
+func getHideLink(node *html.Node) (hidden bool, auth string) {
+	link := htmlquery.QuerySelector(node, hideLinkExpr)
+	if link == nil {
+		return false, ""
+	}
+	u, err := url.Parse(htmlquery.SelectAttr(link, "href"))
+	if err != nil {
+		return false, ""
+	}
+	query := u.Query()
+
+	return query.Get("un") == "t", query.Get("auth")
+}
+
+// isNoprocrast reports whether the page is the one HackerNews shows instead of the requested page
+// while an account's noprocrast setting is in effect.
+func isNoprocrast(doc *html.Node) bool {
+	body := htmlquery.QuerySelector(doc, bodyExpr)
+	return body != nil && strings.Contains(htmlquery.InnerText(body), "anti-procrastination")
+}
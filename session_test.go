@@ -0,0 +1,59 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithSession(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	// Logged-in accounts see an auth token on every hide link, and an un-hide link on hidden posts
+	page := strings.NewReplacer(
+		"hide?id=28888001&amp;goto=news", "hide?id=28888001&amp;auth=abc123&amp;goto=news",
+		"hide?id=28888002&amp;goto=news", "hide?id=28888002&amp;un=t&amp;auth=def456&amp;goto=news",
+	).Replace(string(fixture))
+
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("user"); err != nil || cookie.Value != "alice&secret" {
+			t.Error("requested without the session cookie")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}, WithSession("alice&secret"))
+
+	result, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	posts := result.Posts
+	if posts[0].Hidden || posts[0].HideAuth != "abc123" {
+		t.Errorf("parsed visible post incorrectly: %+v", posts[0])
+	}
+	if !posts[1].Hidden || posts[1].HideAuth != "def456" {
+		t.Errorf("parsed hidden post incorrectly: %+v", posts[1])
+	}
+	if posts[2].Hidden || posts[2].HideAuth != "" {
+		t.Errorf("parsed post without a token incorrectly: %+v", posts[2])
+	}
+}
+
+func TestNoprocrast(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><b>Get back to work!</b><p>Sorry, you can't see this page. Based on the ` +
+			`anti-procrastination parameters you set in your profile, you'll be able to use the site again in 42 minutes.</body></html>`))
+	}, WithSession("alice&secret"))
+
+	if _, err := s.ScrapePage(1); !errors.Is(err, ErrNoprocrast) {
+		t.Error("returned ", err, " instead of ErrNoprocrast for a listing")
+	}
+	if _, err := s.ScrapeItem(1); !errors.Is(err, ErrNoprocrast) {
+		t.Error("returned ", err, " instead of ErrNoprocrast for an item")
+	}
+}
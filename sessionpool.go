@@ -0,0 +1,160 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// ErrNoSessions is returned when every session in a SessionPool has expired.
+var ErrNoSessions = errors.New("no live sessions left in pool")
+
+// loginLinkExpr finds the login link HackerNews shows in the top bar to visitors who aren't logged in
+var loginLinkExpr = xpath.MustCompile("//span[contains(@class, 'pagetop')]/a[starts-with(@href, 'login')]")
+
+// A SessionPool spreads a Scraper's requests over several logged-in accounts, see WithSessionPool.
+// Each request goes to the account that has been idle longest, waiting if even that one was used
+// less than the pool's interval ago, so no single account is polled harder than the interval allows.
+// Accounts whose session has expired are taken out of rotation.
+type SessionPool struct {
+	interval time.Duration // The minimum time between two requests as the same account
+
+	mu       sync.Mutex
+	sessions []*pooledSession
+}
+
+type pooledSession struct {
+	cookie   string    // The value of the "user" cookie
+	lastUsed time.Time // When the session's most recent request was allowed to start
+	expired  bool      // Whether a request with the session came back logged out
+}
+
+// NewSessionPool creates a pool of the sessions, given as the values of the "user" cookie as for WithSession,
+// that makes at most one request as each account every interval.
+func NewSessionPool(interval time.Duration, cookies ...string) *SessionPool {
+	p := &SessionPool{interval: interval}
+	for _, cookie := range cookies {
+		p.Add(cookie)
+	}
+
+	return p
+}
+
+// WithSessionPool makes every request as one of the pool's accounts, rotating between them.
+// It suits authenticated monitoring; actions, submissions, and replies need a single account and still require
+// WithSession, which takes precedence over the pool for every request when both are set.
+// The same pool can be shared by several Scrapers. Waiting for an account's turn counts against WithRequestTimeout.
+func WithSessionPool(pool *SessionPool) Option {
+	return func(s *Scraper) {
+		s.sessionPool = pool
+	}
+}
+
+// Add puts a session into rotation, ie. to replace one that expired. Adding a session that is already
+// in the pool revives it if it had expired.
+func (p *SessionPool) Add(cookie string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, session := range p.sessions {
+		if session.cookie == cookie {
+			session.expired = false
+			return
+		}
+	}
+	p.sessions = append(p.sessions, &pooledSession{cookie: cookie})
+}
+
+// Live returns how many sessions are still in rotation.
+func (p *SessionPool) Live() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	live := 0
+	for _, session := range p.sessions {
+		if !session.expired {
+			live++
+		}
+	}
+
+	return live
+}
+
+// Expired returns the usernames of the accounts whose sessions have expired, so they can be logged in again.
+func (p *SessionPool) Expired() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var users []string
+	for _, session := range p.sessions {
+		if session.expired {
+			users = append(users, cookieUsername(session.cookie))
+		}
+	}
+
+	return users
+}
+
+// take reserves the next turn of the least recently used live session and waits for it.
+func (p *SessionPool) take(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	var next *pooledSession
+	for _, session := range p.sessions {
+		if !session.expired && (next == nil || session.lastUsed.Before(next.lastUsed)) {
+			next = session
+		}
+	}
+	if next == nil {
+		p.mu.Unlock()
+		return "", ErrNoSessions
+	}
+	turn := next.lastUsed.Add(p.interval)
+	if now := time.Now(); turn.Before(now) {
+		turn = now
+	}
+	next.lastUsed = turn
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(turn))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return next.cookie, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// expire takes the session out of rotation, reporting whether it was still live.
+func (p *SessionPool) expire(cookie string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, session := range p.sessions {
+		if session.cookie == cookie && !session.expired {
+			session.expired = true
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPooledSession takes a pooled session out of rotation if the page it fetched was served logged out.
+// r is the body handed to a fetch's reader, which says which session the request was made with.
+func (s *Scraper) checkPooledSession(r io.Reader, doc *html.Node) {
+	mr, ok := r.(*metaReader)
+	if !ok || mr.pooled == "" || htmlquery.QuerySelector(doc, loginLinkExpr) == nil {
+		return
+	}
+	if s.sessionPool.expire(mr.pooled) {
+		s.logf("session for %s expired, %d left in pool", cookieUsername(mr.pooled), s.sessionPool.Live())
+	}
+}
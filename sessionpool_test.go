@@ -0,0 +1,105 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// servePooled serves the listing fixture logged in as the cookie's account, or logged out for the expired accounts,
+// recording which account every request was made as.
+func servePooled(t *testing.T, expired ...string) (http.HandlerFunc, func() []string) {
+	fixture, err := os.ReadFile("testdata/news.html")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	var mu sync.Mutex
+	var users []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("user")
+		if err != nil {
+			t.Error("requested without a session cookie")
+			return
+		}
+		user := cookieUsername(cookie.Value)
+		mu.Lock()
+		users = append(users, user)
+		mu.Unlock()
+
+		page := string(fixture)
+		loggedIn := true
+		for _, e := range expired {
+			loggedIn = loggedIn && e != user
+		}
+		if loggedIn {
+			page = strings.Replace(page, `<a href="login?goto=news">login</a>`, `<a href="user?id=`+user+`">`+user+`</a>`, 1)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}
+
+	return handler, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), users...)
+	}
+}
+
+func TestSessionPoolRotation(t *testing.T) {
+	handler, users := servePooled(t)
+	pool := NewSessionPool(0, "alice&a", "bob&b", "carol&c")
+	s := newTestScraper(t, handler, WithSessionPool(pool))
+
+	if _, err := s.ScrapeMultPages(1, 6); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if got := strings.Join(users(), ","); got != "alice,bob,carol,alice,bob,carol" {
+		t.Error("rotated through accounts ", got)
+	}
+}
+
+func TestSessionPoolExpiry(t *testing.T) {
+	handler, users := servePooled(t, "bob")
+	pool := NewSessionPool(0, "alice&a", "bob&b")
+	s := newTestScraper(t, handler, WithSessionPool(pool))
+
+	if _, err := s.ScrapeMultPages(1, 4); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if got := strings.Join(users(), ","); got != "alice,bob,alice,alice" {
+		t.Error("made requests as ", got, " after bob's session expired")
+	}
+	if pool.Live() != 1 || len(pool.Expired()) != 1 || pool.Expired()[0] != "bob" {
+		t.Error("tracked ", pool.Live(), " live sessions and expired ", pool.Expired())
+	}
+
+	// Logging back in puts the account back into rotation
+	pool.Add("bob&b")
+	if pool.Live() != 2 {
+		t.Error("didn't revive the session")
+	}
+
+	empty := newTestScraper(t, handler, WithSessionPool(NewSessionPool(0)))
+	if _, err := empty.ScrapePage(1); !errors.Is(err, ErrNoSessions) {
+		t.Error("returned ", err, " instead of ErrNoSessions")
+	}
+}
+
+func TestSessionPoolInterval(t *testing.T) {
+	handler, _ := servePooled(t)
+	interval := 50 * time.Millisecond
+	s := newTestScraper(t, handler, WithSessionPool(NewSessionPool(interval, "alice&a")))
+
+	start := time.Now()
+	if _, err := s.ScrapeMultPages(1, 3); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Error("made three requests as one account in ", elapsed)
+	}
+}
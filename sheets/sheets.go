@@ -0,0 +1,169 @@
+// Package sheets appends scraped posts to a Google Sheet, for the people who would rather not touch a database.
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/googleauth"
+)
+
+// Scope is the OAuth2 scope the service account's credentials need.
+const Scope = "https://www.googleapis.com/auth/spreadsheets"
+
+const defaultAPIURL = "https://sheets.googleapis.com/v4/"
+
+// A Column maps a post to a cell.
+type Column struct {
+	Name  string                             // The column's header
+	Value func(p hnscraper.Post) interface{} // The cell's value, a string, number, or bool
+}
+
+// DefaultColumns are the columns written when a Sink has none set.
+var DefaultColumns = []Column{
+	{"ID", func(p hnscraper.Post) interface{} { return p.ID }},
+	{"Rank", func(p hnscraper.Post) interface{} { return p.Rank }},
+	{"Title", func(p hnscraper.Post) interface{} { return p.Title }},
+	{"Score", func(p hnscraper.Post) interface{} { return p.Score }},
+	{"By", func(p hnscraper.Post) interface{} { return p.By }},
+	{"URL", func(p hnscraper.Post) interface{} { return p.URL }},
+	{"Comments", func(p hnscraper.Post) interface{} { return p.NumComments }},
+	{"Posted", func(p hnscraper.Post) interface{} { return p.TimePosted.UTC().Format(time.RFC3339) }},
+}
+
+// A Sink is a pipeline.Sink that appends each post as a row of a Google Sheet.
+// Rows are buffered and appended in batches to stay within the API's quotas,
+// so Close must be called to append the last batch.
+// The service account must have been given edit access to the spreadsheet.
+type Sink struct {
+	SpreadsheetID string                  // The ID in the spreadsheet's URL
+	Sheet         string                  // The name of the sheet to append to, "Sheet1" if empty
+	Credentials   *googleauth.Credentials // A service account with the spreadsheets Scope
+	Columns       []Column                // The columns to write, DefaultColumns if nil
+	Header        bool                    // Whether to append a row of column names before the first batch
+	BatchSize     int                     // How many rows are appended at once, 0 for 100
+	Client        *http.Client            // The client to call the API with, nil for http.DefaultClient
+	APIURL        string                  // The Sheets API's base URL, the Google endpoint if empty
+
+	mu     sync.Mutex
+	rows   [][]interface{}
+	headed bool
+}
+
+// Write buffers the post as a row, appending the batch once it is full.
+func (s *Sink) Write(ctx context.Context, post hnscraper.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Header && !s.headed {
+		var header []interface{}
+		for _, column := range s.columns() {
+			header = append(header, column.Name)
+		}
+		s.rows = append(s.rows, header)
+		s.headed = true
+	}
+
+	var row []interface{}
+	for _, column := range s.columns() {
+		row = append(row, column.Value(post))
+	}
+	s.rows = append(s.rows, row)
+
+	batchSize := s.BatchSize
+	if batchSize == 0 {
+		batchSize = 100
+	}
+	if len(s.rows) < batchSize {
+		return nil
+	}
+
+	return s.flush(ctx)
+}
+
+// Close appends any buffered rows.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush(context.Background())
+}
+
+func (s *Sink) columns() []Column {
+	if s.Columns == nil {
+		return DefaultColumns
+	}
+
+	return s.Columns
+}
+
+// flush appends the buffered rows. The caller must hold the lock.
+func (s *Sink) flush(ctx context.Context) error {
+	if len(s.rows) == 0 {
+		return nil
+	}
+	if s.Credentials == nil {
+		return errors.New("sheets sink has no credentials")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"values": s.rows})
+	if err != nil {
+		return err
+	}
+
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	sheet := s.Sheet
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	endpoint := apiURL + "spreadsheets/" + url.PathEscape(s.SpreadsheetID) +
+		"/values/" + url.PathEscape(sheetName(sheet)) + ":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS"
+
+	token, err := s.Credentials.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sheets API responded with status %d", resp.StatusCode)
+	}
+
+	s.rows = s.rows[:0]
+	return nil
+}
+
+// sheetName quotes a sheet name for use in an A1 range if it needs it.
+func sheetName(name string) string {
+	if strings.ContainsAny(name, " '!") {
+		return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	}
+
+	return name
+}
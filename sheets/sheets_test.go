@@ -0,0 +1,78 @@
+package sheets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/googleauth"
+	"github.com/thetallpaul/hnscraper/pipeline"
+)
+
+func TestSink(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][][]interface{}
+	var paths []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "secret", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/spreadsheets/", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Values [][]interface{} }
+		if r.Header.Get("Authorization") != "Bearer secret" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, body.Values)
+		paths = append(paths, r.URL.EscapedPath())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	sink := &Sink{
+		SpreadsheetID: "abc123",
+		Sheet:         "HN posts",
+		Credentials:   &googleauth.Credentials{Email: "scraper@example.com", Key: key, TokenURL: server.URL + "/token"},
+		Header:        true,
+		BatchSize:     2,
+		APIURL:        server.URL + "/",
+	}
+
+	posted := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	posts := []hnscraper.Post{
+		{ID: 1, Title: "First", Score: 10, TimePosted: posted},
+		{ID: 2, Title: "Second", Score: 20, TimePosted: posted},
+		{ID: 3, Title: "Third", Score: 30, TimePosted: posted},
+	}
+	if err := pipeline.New(pipeline.Posts(posts)).To(sink).Run(context.Background()); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The header and first post fill the first batch
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 2 {
+		t.Fatal("appended batches ", batches)
+	}
+	if batches[0][0][0] != "ID" || batches[0][1][2] != "First" || batches[0][1][7] != "2021-10-16T12:00:00Z" {
+		t.Error("appended first batch ", batches[0])
+	}
+	if paths[0] != "/spreadsheets/abc123/values/%27HN%20posts%27:append" {
+		t.Error("appended to ", paths[0])
+	}
+}
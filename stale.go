@@ -0,0 +1,72 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// A PageCache keeps the most recent snapshot of each listing page, see WithStaleCache.
+// store.FileStore is one.
+type PageCache interface {
+	// PutPage keeps a snapshot of the page.
+	PutPage(page Page) error
+	// LatestPage returns the most recently retrieved snapshot of the page number, and false if there is none.
+	LatestPage(num int) (Page, bool, error)
+}
+
+// WithStaleCache keeps every listing page scraped in the cache, and serves the most recent snapshot
+// when HackerNews can't be reached rather than failing: the request fails to connect, times out, is throttled,
+// gets a server error, or is stopped by the circuit breaker. Stale pages have Page.Stale set.
+// Pages that were served but couldn't be parsed still fail, as do pages that were never cached.
+func WithStaleCache(cache PageCache) Option {
+	return func(s *Scraper) {
+		s.staleCache = cache
+	}
+}
+
+// Age returns how long ago the page was retrieved.
+func (page Page) Age() time.Duration {
+	return time.Since(page.Retrieved)
+}
+
+// cachePage keeps a freshly scraped page in the stale cache. Failing to is only logged, as the page itself is fine.
+func (s *Scraper) cachePage(page Page) {
+	if s.staleCache == nil {
+		return
+	}
+	if err := s.staleCache.PutPage(page); err != nil {
+		s.logf("caching page %d: %v", page.Num, err)
+	}
+}
+
+// stalePage returns the cached snapshot of the page number if err means HackerNews is unreachable,
+// and false if the error should be returned instead.
+func (s *Scraper) stalePage(ctx context.Context, pageNum int, err error) (Page, bool) {
+	if s.staleCache == nil || !unreachable(ctx, err) {
+		return Page{}, false
+	}
+
+	page, ok, cacheErr := s.staleCache.LatestPage(pageNum)
+	if cacheErr != nil {
+		s.logf("reading cached page %d: %v", pageNum, cacheErr)
+		return Page{}, false
+	} else if !ok {
+		return Page{}, false
+	}
+	s.logf("serving page %d from %s ago: %v", pageNum, page.Age().Round(time.Second), err)
+	page.Stale = true
+
+	return page, true
+}
+
+// unreachable reports whether a failed request means HackerNews is down or refusing requests,
+// rather than the caller cancelling or the page being unparseable.
+func unreachable(ctx context.Context, err error) bool {
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return false
+	}
+
+	return retryable(err, false) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrThrottled) || errors.Is(err, ErrCircuitOpen)
+}
@@ -0,0 +1,100 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// memoryCache is a PageCache for tests, as the store package can't be imported here.
+type memoryCache struct {
+	mu    sync.Mutex
+	pages map[int]Page
+}
+
+func (c *memoryCache) PutPage(page Page) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pages == nil {
+		c.pages = make(map[int]Page)
+	}
+	c.pages[page.Num] = page
+	return nil
+}
+
+func (c *memoryCache) LatestPage(num int) (Page, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	page, ok := c.pages[num]
+	return page, ok, nil
+}
+
+func TestStaleCache(t *testing.T) {
+	var down int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			http.Error(w, "Sorry.", http.StatusServiceUnavailable)
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}
+	cache := &memoryCache{}
+	s := newTestScraper(t, handler, WithStaleCache(cache))
+
+	fresh, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if fresh.Stale {
+		t.Error("marked a fresh page stale")
+	}
+
+	atomic.StoreInt32(&down, 1)
+	stale, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if !stale.Stale || len(stale.Posts) != len(fresh.Posts) || !stale.Retrieved.Equal(fresh.Retrieved) {
+		t.Error("did not serve the cached snapshot: ", stale.Stale, len(stale.Posts))
+	}
+	if stale.Age() <= 0 {
+		t.Error("stale page has age ", stale.Age())
+	}
+
+	if _, err := s.ScrapePage(2); !errors.Is(err, ErrThrottled) {
+		t.Error("returned ", err, " for a page that was never cached")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.scrapePage(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Error("served a stale page after the caller cancelled: ", err)
+	}
+}
+
+func TestStaleCacheParseError(t *testing.T) {
+	var broken int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&broken) == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Nothing here</body></html>"))
+			return
+		}
+		http.ServeFile(w, r, "testdata/news.html")
+	}
+	s := newTestScraper(t, handler, WithStaleCache(&memoryCache{}))
+	if _, err := s.ScrapePage(1); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	atomic.StoreInt32(&broken, 1)
+	var pageErr *PageError
+	if _, err := s.ScrapePage(1); !errors.As(err, &pageErr) {
+		t.Error("returned ", err, " instead of the parse error")
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// titleSimilarity is the share of title words two posts must have in common to be considered the same story
+const titleSimilarity = 0.8
+
+// minTitleWords is the fewest words a title needs to be matched by similarity, as short titles collide too easily
+const minTitleWords = 3
+
+// FindDuplicates returns the stored posts that are earlier submissions of the same story as post, oldest first.
+// A stored post is an earlier submission if it has a lower item ID and either links to the same canonical URL,
+// or has a near-identical title: after lowercasing and dropping punctuation and the year HN appends to reposts,
+// the titles must share at least 80% of their combined words.
+func FindDuplicates(st Store, post hnscraper.Post) ([]hnscraper.Post, error) {
+	stored, err := st.Posts()
+	if err != nil {
+		return nil, err
+	}
+
+	// Posts without an external link only match by title
+	canonical := ""
+	if post.Domain() != "" {
+		canonical = hnscraper.CanonicalURL(post.URL)
+	}
+	words := titleWords(post.Title)
+
+	var dups []hnscraper.Post
+	for _, other := range stored {
+		if other.ID >= post.ID {
+			continue
+		}
+		sameURL := canonical != "" && other.Domain() != "" && hnscraper.CanonicalURL(other.URL) == canonical
+		if sameURL || similarTitles(words, titleWords(other.Title)) {
+			dups = append(dups, other)
+		}
+	}
+
+	sort.Slice(dups, func(i, j int) bool {
+		return dups[i].ID < dups[j].ID
+	})
+	return dups, nil
+}
+
+// titleWords returns the set of lowercased words in the title, leaving out years such as the "(2019)" added to reposts.
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if year, err := strconv.Atoi(word); err == nil && len(word) == 4 && year >= 1900 && year < 2100 {
+			continue
+		}
+		words[word] = true
+	}
+
+	return words
+}
+
+// similarTitles reports whether the two sets of words overlap by at least titleSimilarity.
+func similarTitles(a, b map[string]bool) bool {
+	if len(a) < minTitleWords || len(b) < minTitleWords {
+		return false
+	}
+
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+
+	return float64(shared) >= titleSimilarity*float64(union)
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	st := NewMemory()
+	err := st.PutPage(hnscraper.Page{Num: 1, Retrieved: base, Posts: []hnscraper.Post{
+		{ID: 10, Title: "The Unreasonable Effectiveness of Recurrent Neural Networks", URL: "https://karpathy.github.io/2015/05/21/rnn-effectiveness/"},
+		{ID: 20, Title: "Show HN: My weekend project", URL: "https://example.com/project"},
+		{ID: 30, Title: "A different story about neural networks", URL: "https://example.org/rnn"},
+		{ID: 40, Title: "The unreasonable effectiveness of recurrent neural networks (2015)", URL: "https://example.net/mirror"},
+		{ID: 50, Title: "Ask HN: Who is hiring?", URL: "item?id=50"},
+		{ID: 60, Title: "Ask HN: Who is hiring?", URL: "item?id=60"},
+		{ID: 99, Title: "Neural networks, revisited", URL: "http://www.karpathy.github.io/2015/05/21/rnn-effectiveness?utm_source=hn"},
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	repost := hnscraper.Post{ID: 90, Title: "The Unreasonable Effectiveness of Recurrent Neural Networks (2015)",
+		URL: "https://www.karpathy.github.io/2015/05/21/rnn-effectiveness/"}
+	dups, err := FindDuplicates(st, repost)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	// Post 99 links to the same page but was submitted later
+	if len(dups) != 2 || dups[0].ID != 10 || dups[1].ID != 40 {
+		t.Error("found duplicates ", dups, " instead of posts 10 and 40")
+	}
+
+	// Self posts match by title alone
+	dups, err = FindDuplicates(st, hnscraper.Post{ID: 70, Title: "Ask HN: Who is hiring?", URL: "item?id=70"})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if len(dups) != 2 || dups[0].ID != 50 || dups[1].ID != 60 {
+		t.Error("found duplicates ", dups, " instead of posts 50 and 60")
+	}
+
+	if dups, _ := FindDuplicates(st, hnscraper.Post{ID: 80, Title: "Something new", URL: "https://example.com/new"}); len(dups) != 0 {
+		t.Error("found duplicates of an original post: ", dups)
+	}
+}
@@ -0,0 +1,257 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// ErrClosed is returned when writing to a store that was already closed.
+var ErrClosed = errors.New("store is closed")
+
+// A FileStore is an embedded, pure-Go Store that needs no database server or CGO.
+// Snapshots are appended to a single file as lines of JSON and synced to disk before PutPage returns.
+// The whole store is loaded into memory when opened, with posts indexed by Post.Key and snapshots by retrieval time,
+// so posts scraped without an item ID are kept apart too.
+// A FileStore is safe for concurrent use within one process.
+type FileStore struct {
+	mu        sync.RWMutex
+	file      *os.File                  // The append-only log, nil for a memory-only store
+	closed    bool                      // Whether Close was called
+	posts     map[string]hnscraper.Post // The latest version of each post, by Post.Key
+	retrieved map[string]time.Time      // When the latest version of each post was retrieved, by Post.Key
+	snapshots []hnscraper.Page          // Every snapshot, sorted by retrieval time
+	retention Retention                 // What Compact keeps
+}
+
+// record is a single line of the log: either a snapshot, or the latest version of a post
+// whose snapshot was dropped by Compact.
+type record struct {
+	Page *hnscraper.Page `json:"page,omitempty"`
+	Post *latestPost     `json:"post,omitempty"`
+}
+
+// latestPost is a post and when it was retrieved.
+type latestPost struct {
+	Post      hnscraper.Post `json:"post"`
+	Retrieved time.Time      `json:"retrieved"`
+}
+
+// Open opens the store at path, creating the file if it doesn't exist.
+// A last record left incomplete by a crash while appending is cut off, but a damaged record before it fails the open.
+func Open(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewMemory()
+	s.file = file
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads the log into memory, truncating a torn last record.
+func (s *FileStore) load() error {
+	r := bufio.NewReaderSize(s.file, 64*1024)
+	var offset int64 // Where the line being read starts
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				// Only the last line can have been cut short by a crash
+				if _, err := r.Peek(1); !errors.Is(err, io.EOF) {
+					return fmt.Errorf("record at byte %d: %w", offset, err)
+				}
+				return s.file.Truncate(offset)
+			}
+			s.apply(rec)
+			// A complete record that only lost its newline would have the next append run into it
+			if line[len(line)-1] != '\n' {
+				if _, err := s.file.Write([]byte{'\n'}); err != nil {
+					return err
+				}
+			}
+		}
+		offset += int64(len(line))
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+// NewMemory creates a FileStore that is never written to disk, for tests and short-lived processes.
+func NewMemory() *FileStore {
+	return &FileStore{
+		posts:     make(map[string]hnscraper.Post),
+		retrieved: make(map[string]time.Time),
+	}
+}
+
+// apply adds a record to the in-memory indexes. The caller must hold the lock.
+func (s *FileStore) apply(rec record) {
+	if rec.Post != nil {
+		s.update(rec.Post.Post, rec.Post.Retrieved)
+	}
+	if rec.Page == nil {
+		return
+	}
+	page := *rec.Page
+
+	i := sort.Search(len(s.snapshots), func(i int) bool {
+		return s.snapshots[i].Retrieved.After(page.Retrieved)
+	})
+	s.snapshots = append(s.snapshots, hnscraper.Page{})
+	copy(s.snapshots[i+1:], s.snapshots[i:])
+	s.snapshots[i] = page
+
+	for _, post := range page.Posts {
+		s.update(post, page.Retrieved)
+	}
+}
+
+// update keeps the post if it is the latest version retrieved. The caller must hold the lock.
+func (s *FileStore) update(post hnscraper.Post, retrieved time.Time) {
+	key := post.Key()
+	if last, ok := s.retrieved[key]; !ok || !retrieved.Before(last) {
+		s.posts[key] = post
+		s.retrieved[key] = retrieved
+	}
+}
+
+// write appends a record to the log and syncs it. The caller must hold the lock.
+func (s *FileStore) write(rec record) error {
+	if s.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// PutPage stores a snapshot of the page and updates the posts on it.
+func (s *FileStore) PutPage(page hnscraper.Page) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	rec := record{Page: &page}
+	if err := s.write(rec); err != nil {
+		return err
+	}
+	s.apply(rec)
+
+	return nil
+}
+
+// Post returns the most recently retrieved version of the post, and false if it was never stored.
+func (s *FileStore) Post(id int) (hnscraper.Post, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[hnscraper.Post{ID: id}.Key()]
+	return post, ok, nil
+}
+
+// Posts returns the most recently retrieved version of every stored post, in no particular order.
+func (s *FileStore) Posts() ([]hnscraper.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]hnscraper.Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// Query returns the latest version of the stored posts matching q, newest first.
+func (s *FileStore) Query(q Query) ([]hnscraper.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := q.filter()
+	var posts []hnscraper.Post
+	for _, post := range s.posts {
+		if match(post) {
+			posts = append(posts, post)
+		}
+	}
+
+	return q.page(posts), nil
+}
+
+// Snapshots returns the page snapshots retrieved within [from, to), oldest first.
+func (s *FileStore) Snapshots(from, to time.Time) ([]hnscraper.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := sort.Search(len(s.snapshots), func(i int) bool {
+		return !s.snapshots[i].Retrieved.Before(from)
+	})
+	end := sort.Search(len(s.snapshots), func(i int) bool {
+		return !s.snapshots[i].Retrieved.Before(to)
+	})
+	if start >= end {
+		return nil, nil
+	}
+
+	return append([]hnscraper.Page(nil), s.snapshots[start:end]...), nil
+}
+
+// LatestPage returns the most recently retrieved snapshot of the page number, and false if there is none.
+// It lets a FileStore serve as the scraper's hnscraper.PageCache.
+func (s *FileStore) LatestPage(num int) (hnscraper.Page, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].Num == num {
+			return s.snapshots[i], true, nil
+		}
+	}
+
+	return hnscraper.Page{}, false, nil
+}
+
+// Close closes the store's file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+var base = time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hn.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	pages := []hnscraper.Page{
+		{Num: 1, Retrieved: base.Add(time.Hour), Posts: []hnscraper.Post{{ID: 1, Score: 20}, {ID: 2, Score: 3}}},
+		{Num: 1, Retrieved: base, Posts: []hnscraper.Post{{ID: 1, Score: 10}}},
+	}
+	for _, page := range pages {
+		if err := s.PutPage(page); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := s.PutPage(pages[0]); !errors.Is(err, ErrClosed) {
+		t.Error("returned ", err, " instead of ErrClosed")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	defer reopened.Close()
+
+	if post, ok, _ := reopened.Post(1); !ok || post.Score != 20 {
+		t.Error("kept score ", post.Score, " instead of the latest score of 20")
+	}
+	if _, ok, _ := reopened.Post(3); ok {
+		t.Error("returned a post that was never stored")
+	}
+	if posts, _ := reopened.Posts(); len(posts) != 2 {
+		t.Error("stored ", len(posts), " posts instead of 2")
+	}
+
+	snapshots, _ := reopened.Snapshots(base, base.Add(2*time.Hour))
+	if len(snapshots) != 2 || !snapshots[0].Retrieved.Equal(base) {
+		t.Error("did not return snapshots oldest first")
+	}
+	if snapshots, _ := reopened.Snapshots(base.Add(time.Minute), base.Add(time.Hour)); len(snapshots) != 0 {
+		t.Error("returned snapshots outside the range")
+	}
+
+	if latest, ok, _ := reopened.LatestPage(1); !ok || !latest.Retrieved.Equal(base.Add(time.Hour)) {
+		t.Error("returned snapshot from ", latest.Retrieved, " instead of the latest")
+	}
+	if _, ok, _ := reopened.LatestPage(2); ok {
+		t.Error("returned a page that was never stored")
+	}
+}
+
+func TestFileStoreWithoutIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hn.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	// Scraped without IDs, ie. with WithFields, and retrieved long enough ago for Compact to drop the snapshot
+	page := hnscraper.Page{Num: 1, Retrieved: base, Posts: []hnscraper.Post{
+		{Title: "Rust in production", URL: "https://github.com/a"},
+		{Title: "Go generics", URL: "https://go.dev/blog"},
+	}}
+	if err := s.PutPage(page); err != nil {
+		t.Fatal("error: ", err)
+	}
+	s.SetRetention(Retention{Raw: time.Hour, Expire: time.Hour})
+	if err := s.Compact(); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	defer reopened.Close()
+	if posts, _ := reopened.Posts(); len(posts) != 2 {
+		t.Error("kept ", len(posts), " posts without IDs instead of 2")
+	}
+	if posts, _ := reopened.Query(Query{}); len(posts) != 2 {
+		t.Error("queried ", len(posts), " posts without IDs instead of 2")
+	}
+}
+
+func TestFileStoreQuery(t *testing.T) {
+	s := NewMemory()
+	err := s.PutPage(hnscraper.Page{Num: 1, Retrieved: base, Posts: []hnscraper.Post{
+		{ID: 1, Title: "Rust in production", Score: 200, NumComments: 50, By: "alice", URL: "https://blog.github.com/a", TimePosted: base.Add(-3 * time.Hour)},
+		{ID: 2, Title: "Go generics", Score: 150, NumComments: 5, By: "bob", URL: "https://go.dev/blog", TimePosted: base.Add(-time.Hour)},
+		{ID: 3, Title: "Ask HN: Rust or Go?", Score: 90, NumComments: 120, By: "alice", URL: "item?id=3", TimePosted: base.Add(-2 * time.Hour)},
+		{ID: 4, Title: "Acme is hiring", URL: "https://acme.com/jobs", TimePosted: base, Job: true},
+	}})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		{"all", Query{}, []int{4, 2, 3, 1}},
+		{"score", Query{MinScore: 100}, []int{2, 1}},
+		{"comments", Query{MinComments: 10}, []int{3, 1}},
+		{"since", Query{Since: base.Add(-90 * time.Minute)}, []int{4, 2}},
+		{"domain", Query{Domain: "github.com"}, []int{1}},
+		{"author", Query{Author: "alice"}, []int{3, 1}},
+		{"type", Query{Type: hnscraper.TypeJob}, []int{4}},
+		{"page", Query{Offset: 1, Limit: 2}, []int{2, 3}},
+		{"past end", Query{Offset: 10}, nil},
+	}
+	for _, test := range tests {
+		posts, err := s.Query(test.query)
+		if err != nil {
+			t.Fatal("error: ", err)
+		}
+		var ids []int
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		if len(ids) != len(test.want) {
+			t.Error(test.name, ": returned ", ids, " instead of ", test.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.want[i] {
+				t.Error(test.name, ": returned ", ids, " instead of ", test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFileStoreTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hn.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := s.PutPage(hnscraper.Page{Num: 1, Retrieved: base, Posts: []hnscraper.Post{{ID: 1, Score: 10}}}); err != nil {
+		t.Fatal("error: ", err)
+	}
+	s.Close()
+	intact, _ := os.ReadFile(path)
+
+	// A crash part way through appending the next record
+	if err := os.WriteFile(path, append(intact, `{"page":{"Posts":[{"ID":2`...), 0o644); err != nil {
+		t.Fatal("error: ", err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if posts, _ := s.Posts(); len(posts) != 1 {
+		t.Error("loaded ", len(posts), " posts instead of the 1 before the torn record")
+	}
+	if err := s.PutPage(hnscraper.Page{Num: 1, Retrieved: base.Add(time.Hour), Posts: []hnscraper.Post{{ID: 3}}}); err != nil {
+		t.Fatal("error: ", err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if posts, _ := s.Posts(); len(posts) != 2 {
+		t.Error("loaded ", len(posts), " posts instead of 2 after appending past the torn record")
+	}
+	s.Close()
+
+	// Damage before the last record is not a crash, and isn't repaired
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, append([]byte("{garbage\n"), data...), 0o644); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("opened a store damaged before its last record")
+	}
+}
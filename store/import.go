@@ -0,0 +1,248 @@
+package store
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// The default for ImportOptions.BatchSize
+const defaultImportBatch = 1000
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	BatchSize int                           // How many posts are stored per page, 0 for 1000
+	Comments  func(hnscraper.Comment) error // Receives every comment, nil to skip them, as stores only hold posts
+}
+
+// ImportStats counts what an import read.
+type ImportStats struct {
+	Posts    int // Stories, jobs, and polls stored as posts
+	Comments int // Comments handed to ImportOptions.Comments
+	Skipped  int // Deleted and dead items, poll options, and comments when there is no Comments function
+}
+
+var (
+	tagRegexp       = regexp.MustCompile(`<[^>]*>`)
+	paragraphRegexp = regexp.MustCompile(`(?i)<p>`)
+)
+
+// Import loads a dump of the public HackerNews dataset, as exported from BigQuery's
+// bigquery-public-data.hacker_news.full table or mirrored on Kaggle, into the store, so historical and scraped posts
+// can be queried together. Both of BigQuery's export formats are read: CSV with a header row, and newline-delimited
+// JSON; the format is told from the first byte. Missing columns are left empty.
+//
+// Imported posts are stored in pages numbered 0 and retrieved at the zero time, so any scraped version of a post
+// takes precedence and the imports don't show up among a real period's snapshots.
+func Import(st Store, r io.Reader, opts ImportOptions) (ImportStats, error) {
+	var stats ImportStats
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatch
+	}
+
+	br := bufio.NewReader(r)
+	next, err := rowReader(br)
+	if err != nil {
+		return stats, err
+	}
+
+	var batch []hnscraper.Post
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := st.PutPage(hnscraper.Page{Posts: batch})
+		batch = nil
+		return err
+	}
+	for line := 1; ; line++ {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return stats, fmt.Errorf("row %d: %w", line, err)
+		}
+
+		if row["deleted"] == "true" || row["dead"] == "true" {
+			stats.Skipped++
+			continue
+		}
+		switch row["type"] {
+		case "story", "job", "poll":
+			post, err := importPost(row)
+			if err != nil {
+				return stats, fmt.Errorf("row %d: %w", line, err)
+			}
+			batch = append(batch, post)
+			stats.Posts++
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+		case "comment":
+			if opts.Comments == nil {
+				stats.Skipped++
+				continue
+			}
+			comment, err := importComment(row)
+			if err != nil {
+				return stats, fmt.Errorf("row %d: %w", line, err)
+			}
+			if err := opts.Comments(comment); err != nil {
+				return stats, err
+			}
+			stats.Comments++
+		default:
+			stats.Skipped++
+		}
+	}
+
+	return stats, flush()
+}
+
+// rowReader returns a function reading the dump's rows as column names mapped to values, null values being empty.
+func rowReader(br *bufio.Reader) (func() (map[string]string, error), error) {
+	first, err := br.Peek(1)
+	if errors.Is(err, io.EOF) {
+		return func() (map[string]string, error) { return nil, io.EOF }, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if first[0] == '{' {
+		dec := json.NewDecoder(br)
+		dec.UseNumber()
+		return func() (map[string]string, error) {
+			var values map[string]interface{}
+			if err := dec.Decode(&values); err != nil {
+				return nil, err
+			}
+			row := make(map[string]string, len(values))
+			for name, value := range values {
+				if value != nil {
+					row[name] = fmt.Sprint(value)
+				}
+			}
+			return row, nil
+		}, nil
+	}
+
+	cr := csv.NewReader(br)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	header = append([]string(nil), header...)
+	return func() (map[string]string, error) {
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		return row, nil
+	}, nil
+}
+
+func importPost(row map[string]string) (hnscraper.Post, error) {
+	post := hnscraper.Post{
+		Title:    row["title"],
+		RawTitle: row["title"],
+		By:       row["by"],
+		URL:      row["url"],
+	}
+
+	var err error
+	if post.ID, err = importInt(row, "id"); err != nil {
+		return post, err
+	}
+	if post.Score, err = importInt(row, "score"); err != nil {
+		return post, err
+	}
+	if post.NumComments, err = importInt(row, "descendants"); err != nil {
+		return post, err
+	}
+	post.TimePosted, err = importTime(row)
+
+	// Job ads keep the dataset's submitter and score, which listings don't show
+	if row["type"] == "job" {
+		post.Job, post.Promoted = true, true
+	}
+
+	return post, err
+}
+
+func importComment(row map[string]string) (hnscraper.Comment, error) {
+	comment := hnscraper.Comment{By: row["by"], Text: importText(row["text"])}
+
+	var err error
+	if comment.ID, err = importInt(row, "id"); err != nil {
+		return comment, err
+	}
+	if comment.ParentID, err = importInt(row, "parent"); err != nil {
+		return comment, err
+	}
+	comment.TimePosted, err = importTime(row)
+
+	return comment, err
+}
+
+// importInt reads a numeric column, which BigQuery's JSON export may quote. An empty column is 0.
+func importInt(row map[string]string, name string) (int, error) {
+	value := row[name]
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("column %s: %w", name, err)
+	}
+
+	return n, nil
+}
+
+// importTime reads when an item was posted from its Unix time, or failing that its timestamp,
+// ie. "2021-10-16 12:00:00 UTC".
+func importTime(row map[string]string) (time.Time, error) {
+	if unix, err := importInt(row, "time"); err != nil || unix != 0 {
+		return time.Unix(int64(unix), 0).UTC(), err
+	}
+
+	value := row["timestamp"]
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05.999999 MST", time.RFC3339} {
+		if posted, err := time.Parse(layout, value); err == nil {
+			return posted.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("column timestamp: unknown time format %q", value)
+}
+
+// importText converts the HTML text of a comment to plain text with paragraphs separated by blank lines,
+// as scraped comments are.
+func importText(text string) string {
+	text = paragraphRegexp.ReplaceAllLiteralString(text, "\n\n")
+	text = html.UnescapeString(tagRegexp.ReplaceAllLiteralString(text, ""))
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+const importCSV = `title,url,text,dead,by,score,time,timestamp,type,id,parent,descendants,ranking,deleted
+Rust in production,https://example.com/rust,,,alice,120,1634385600,2021-10-16 12:00:00 UTC,story,28888001,,42,,
+,,"I agree.<p>Mostly &#x27;fine&#x27;, though.",,bob,,1634389200,2021-10-16 13:00:00 UTC,comment,28888010,28888001,,,
+,,,,,,,,story,28888002,,,,true
+Acme is hiring,https://acme.example/jobs,,,acme,1,,2021-10-16 14:00:00 UTC,job,28888003,,,,
+`
+
+const importJSON = `{"title":"Rust in production","url":"https://example.com/rust","by":"alice","score":"120","time":"1634385600","type":"story","id":"28888001","descendants":"42","dead":null}
+{"text":"I agree.","by":"bob","time":1634389200,"type":"comment","id":28888010,"parent":28888001}
+{"title":"Flagged","by":"spammer","type":"story","id":28888002,"dead":true}
+`
+
+func TestImport(t *testing.T) {
+	for name, dump := range map[string]string{"csv": importCSV, "json": importJSON} {
+		st := NewMemory()
+		var comments []hnscraper.Comment
+		stats, err := Import(st, strings.NewReader(dump), ImportOptions{BatchSize: 1, Comments: func(c hnscraper.Comment) error {
+			comments = append(comments, c)
+			return nil
+		}})
+		if err != nil {
+			t.Fatal(name, " error: ", err)
+		}
+		if stats.Comments != 1 || stats.Skipped != 1 {
+			t.Error(name, " imported ", stats)
+		}
+
+		post, ok, _ := st.Post(28888001)
+		if !ok || post.Title != "Rust in production" || post.By != "alice" || post.Score != 120 || post.NumComments != 42 ||
+			!post.TimePosted.Equal(time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s imported story incorrectly: %+v", name, post)
+		}
+		if _, ok, _ := st.Post(28888002); ok {
+			t.Error(name, " imported a dead story")
+		}
+		if len(comments) != 1 || comments[0].ParentID != 28888001 || comments[0].By != "bob" {
+			t.Errorf("%s imported comments incorrectly: %+v", name, comments)
+		}
+
+		// Scraped versions take precedence over imported ones
+		if err := st.PutPage(hnscraper.Page{Num: 1, Retrieved: time.Now(), Posts: []hnscraper.Post{{ID: 28888001, Score: 130}}}); err != nil {
+			t.Fatal("error: ", err)
+		}
+		if post, _, _ := st.Post(28888001); post.Score != 130 {
+			t.Error(name, " kept imported score over the scraped one")
+		}
+	}
+
+	st := NewMemory()
+	if stats, err := Import(st, strings.NewReader(importCSV), ImportOptions{}); err != nil || stats.Posts != 2 || stats.Skipped != 2 {
+		t.Error("imported ", stats, " with ", err)
+	}
+	if job, ok, _ := st.Post(28888003); !ok || job.Type() != hnscraper.TypeJob || job.By != "acme" || job.Score != 1 || job.TimePosted.Hour() != 14 {
+		t.Errorf("imported job incorrectly: %+v", job)
+	}
+}
+
+func TestImportText(t *testing.T) {
+	if got := importText("I agree.<p>Mostly &#x27;fine&#x27;, <i>though</i>."); got != "I agree.\n\nMostly 'fine', though." {
+		t.Errorf("converted text to %q", got)
+	}
+}
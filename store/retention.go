@@ -0,0 +1,145 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// A Retention limits how much history a FileStore keeps once it is compacted, see FileStore.Compact.
+// The zero value keeps everything.
+type Retention struct {
+	Raw      time.Duration // How long every snapshot is kept, 0 to keep them all
+	Interval time.Duration // Older snapshots are thinned to the first of each page in every interval, ie. time.Hour
+	Expire   time.Duration // How long thinned snapshots are kept before being dropped, 0 to keep them
+}
+
+// SetRetention sets the retention Compact applies.
+func (s *FileStore) SetRetention(r Retention) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retention = r
+}
+
+// Compact applies the store's retention to its snapshots and rewrites its file without the dropped ones.
+// Snapshots within the raw period are all kept; older ones are thinned so a post's score history becomes, ie.,
+// hourly, until they expire. The latest version of every post is kept even if the snapshot it came from is dropped.
+// Compact replaces the file atomically, so an interrupted compaction leaves the store as it was.
+// Long-running monitors should call it periodically, ie. from a scheduler job.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	kept := s.retention.apply(s.snapshots, time.Now())
+	// Posts whose latest version was on a dropped snapshot are carried over on their own
+	keptLatest := make(map[string]bool)
+	for _, page := range kept {
+		for _, post := range page.Posts {
+			if key := post.Key(); page.Retrieved.Equal(s.retrieved[key]) {
+				keptLatest[key] = true
+			}
+		}
+	}
+	var orphans []record
+	for key, post := range s.posts {
+		if !keptLatest[key] {
+			orphans = append(orphans, record{Post: &latestPost{Post: post, Retrieved: s.retrieved[key]}})
+		}
+	}
+
+	if s.file != nil {
+		if err := s.rewrite(orphans, kept); err != nil {
+			return err
+		}
+	}
+	s.snapshots = kept
+
+	return nil
+}
+
+// apply returns the snapshots, sorted by retrieval time, that the retention keeps at now.
+func (r Retention) apply(snapshots []hnscraper.Page, now time.Time) []hnscraper.Page {
+	if r.Raw <= 0 {
+		return snapshots
+	}
+	rawFrom := now.Add(-r.Raw)
+
+	kept := make([]hnscraper.Page, 0, len(snapshots))
+	type bucket struct {
+		num   int
+		start time.Time
+	}
+	seen := make(map[bucket]bool)
+	for _, page := range snapshots {
+		switch {
+		case !page.Retrieved.Before(rawFrom):
+			kept = append(kept, page)
+		case r.Expire > 0 && page.Retrieved.Before(now.Add(-r.Expire)):
+		case r.Interval > 0:
+			b := bucket{num: page.Num, start: page.Retrieved.Truncate(r.Interval)}
+			if !seen[b] {
+				seen[b] = true
+				kept = append(kept, page)
+			}
+		}
+	}
+
+	return kept
+}
+
+// rewrite replaces the log with one holding only the records and snapshots, reopening it for appending.
+// The caller must hold the lock.
+func (s *FileStore) rewrite(posts []record, snapshots []hnscraper.Page) error {
+	path := s.file.Name()
+	tmpPath := path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, rec := range posts {
+		if err := enc.Encode(rec); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	for i := range snapshots {
+		if err := enc.Encode(record{Page: &snapshots[i]}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+
+	return nil
+}
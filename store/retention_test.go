@@ -0,0 +1,74 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hn.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	now := time.Now()
+	// A snapshot every 20 minutes for the past two days
+	for retrieved := now.Add(-48 * time.Hour); retrieved.Before(now); retrieved = retrieved.Add(20 * time.Minute) {
+		page := hnscraper.Page{Num: 1, Retrieved: retrieved, Posts: []hnscraper.Post{{ID: 1, Score: int(now.Sub(retrieved).Minutes())}}}
+		if err := s.PutPage(page); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+	// A post that fell off before the raw period, only on the last snapshot of its hour
+	lastHour := now.Add(-30 * time.Hour).Truncate(time.Hour)
+	if err := s.PutPage(hnscraper.Page{Num: 2, Retrieved: lastHour.Add(59 * time.Minute), Posts: []hnscraper.Post{{ID: 2, Score: 7}}}); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := s.PutPage(hnscraper.Page{Num: 2, Retrieved: lastHour.Add(time.Minute), Posts: []hnscraper.Post{{ID: 2, Score: 5}}}); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	s.SetRetention(Retention{Raw: 24 * time.Hour, Interval: time.Hour, Expire: 36 * time.Hour})
+	if err := s.Compact(); err != nil {
+		t.Fatal("error: ", err)
+	}
+	check := func(s *FileStore) {
+		// Every snapshot of the last day is kept, short of the one on its boundary
+		raw, _ := s.Snapshots(now.Add(-24*time.Hour+time.Minute), now)
+		if len(raw) != 71 {
+			t.Error("kept ", len(raw), " raw snapshots instead of 71")
+		}
+		thinned, _ := s.Snapshots(now.Add(-36*time.Hour), now.Add(-24*time.Hour+time.Minute))
+		if len(thinned) < 12 || len(thinned) > 14 {
+			t.Error("kept ", len(thinned), " thinned snapshots instead of about one an hour")
+		}
+		if expired, _ := s.Snapshots(time.Time{}, now.Add(-36*time.Hour)); len(expired) != 0 {
+			t.Error("kept ", len(expired), " expired snapshots")
+		}
+		if post, ok, _ := s.Post(2); !ok || post.Score != 7 {
+			t.Error("lost the latest version of a post whose snapshot was dropped: ", post)
+		}
+	}
+	check(s)
+
+	// Compacting again writes to the new file
+	if err := s.PutPage(hnscraper.Page{Num: 1, Retrieved: now, Posts: []hnscraper.Post{{ID: 1}}}); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("error: ", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	defer reopened.Close()
+	check(reopened)
+	if latest, _ := reopened.Snapshots(now, now.Add(time.Second)); len(latest) != 1 {
+		t.Error("lost the snapshot stored after compacting")
+	}
+}
@@ -0,0 +1,81 @@
+// Package store persists scraped pages so posts and their history outlive the process that scraped them.
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+	"github.com/thetallpaul/hnscraper/filter"
+)
+
+// A Store keeps every page snapshot it is given and the latest version of every post on them.
+type Store interface {
+	// PutPage stores a snapshot of the page and updates the posts on it.
+	PutPage(page hnscraper.Page) error
+	// Post returns the most recently retrieved version of the post, and false if it was never stored.
+	Post(id int) (hnscraper.Post, bool, error)
+	// Posts returns the most recently retrieved version of every stored post, in no particular order.
+	Posts() ([]hnscraper.Post, error)
+	// Query returns the latest version of the stored posts matching q, newest first.
+	Query(q Query) ([]hnscraper.Post, error)
+	// Snapshots returns the page snapshots retrieved within [from, to), oldest first.
+	Snapshots(from, to time.Time) ([]hnscraper.Page, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// A Query selects stored posts. A post must match every criterion that is set.
+type Query struct {
+	MinScore    int       // The lowest score a post can have
+	MinComments int       // The fewest comments a post can have
+	Since       time.Time // The earliest a post can have been submitted
+	Domain      string    // The domain the post must link to, including subdomains
+	Author      string    // The user that must have submitted the post
+	Type        string    // The post's type, see hnscraper.Post.Type
+	Offset      int       // How many matching posts to skip
+	Limit       int       // The most posts to return, 0 for all of them
+}
+
+// filter combines the query's criteria.
+func (q Query) filter() filter.Filter {
+	filters := []filter.Filter{
+		filter.ByMinScore(q.MinScore),
+		func(p hnscraper.Post) bool { return p.NumComments >= q.MinComments },
+	}
+	if !q.Since.IsZero() {
+		filters = append(filters, filter.Since(q.Since))
+	}
+	if q.Domain != "" {
+		filters = append(filters, filter.ByDomain(q.Domain))
+	}
+	if q.Author != "" {
+		filters = append(filters, filter.ByAuthor(q.Author))
+	}
+	if q.Type != "" {
+		filters = append(filters, func(p hnscraper.Post) bool { return p.Type() == q.Type })
+	}
+
+	return filter.And(filters...)
+}
+
+// page sorts the matching posts newest first, with the ID breaking ties so pages are stable,
+// and cuts out the requested window.
+func (q Query) page(posts []hnscraper.Post) []hnscraper.Post {
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].TimePosted.Equal(posts[j].TimePosted) {
+			return posts[i].TimePosted.After(posts[j].TimePosted)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	if q.Offset >= len(posts) {
+		return nil
+	}
+	posts = posts[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(posts) {
+		posts = posts[:q.Limit]
+	}
+
+	return posts
+}
@@ -0,0 +1,283 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ErrCommentTooLarge is returned by StreamComments when a comment's text, or any single piece of markup,
+// is larger than the limit set by WithCommentSizeLimit.
+var ErrCommentTooLarge = errors.New("comment exceeds size limit")
+
+// The default limit for WithCommentSizeLimit. HN caps comments well below this.
+const defaultCommentSizeLimit = 1 << 20
+
+// StreamComments scrapes the comments of an item without building the page's DOM, calling fn with each
+// comment in page order. Only the comment being parsed is held in memory, so pages with thousands of
+// comments can be processed in a small, bounded amount of memory. The exception is a Scraper that archives pages,
+// see WithArchiveDir and WithArchiveFunc, which reads the whole page, up to WithMaxBodySize, before streaming it.
+// Comments are passed flat, without Replies; use Depth and ParentID to rebuild the tree.
+// An error from fn stops the scrape and is returned.
+func StreamComments(ctx context.Context, id int, fn func(Comment) error) error {
+	return defaultScraper.StreamComments(ctx, id, fn)
+}
+
+// StreamComments scrapes the comments of an item without building the page's DOM, calling fn with each
+// comment in page order. Only the comment being parsed is held in memory, so pages with thousands of
+// comments can be processed in a small, bounded amount of memory. The exception is a Scraper that archives pages,
+// see WithArchiveDir and WithArchiveFunc, which reads the whole page, up to WithMaxBodySize, before streaming it.
+// Comments are passed flat, without Replies; use Depth and ParentID to rebuild the tree.
+// An error from fn stops the scrape and is returned.
+func (s *Scraper) StreamComments(ctx context.Context, id int, fn func(Comment) error) error {
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	// Retrying after some comments were already passed to fn would pass them again
+	return s.fetchBody(ctx, s.baseURL+"item?id="+strconv.Itoa(id), false, func(r io.Reader) error {
+		return s.streamComments(r, id, time.Now(), fn)
+	})
+}
+
+// commentState is the comment being streamed and where the tokenizer is within it.
+type commentState struct {
+	comment Comment
+
+	expectSpacer bool // Whether the indent cell had no indent attribute, so the depth comes from its spacer image
+	inAuthor     bool
+	ageSpans     int // How many spans deep into the age the tokenizer is, 0 outside it
+	ageTitle     string
+	age          strings.Builder
+
+	textSpans  int // How many spans deep into the comment text the tokenizer is, 0 outside it
+	skipDivs   int // How many divs deep into the reply link the tokenizer is, 0 outside it
+	inLink     bool
+	linkHref   string
+	link       strings.Builder
+	paragraph  strings.Builder
+	paragraphs []string
+	size       int // How many bytes of text have been buffered
+}
+
+func (c *commentState) flush() {
+	if paragraph := strings.TrimSpace(c.paragraph.String()); paragraph != "" {
+		c.paragraphs = append(c.paragraphs, paragraph)
+	}
+	c.paragraph.Reset()
+}
+
+// streamComments tokenizes an item page, calling fn with each comment as soon as it has been read.
+func (s *Scraper) streamComments(r io.Reader, storyID int, retrieved time.Time, fn func(Comment) error) error {
+	limit := s.commentSizeLimit
+	if limit == 0 {
+		limit = defaultCommentSizeLimit
+	}
+
+	z := html.NewTokenizer(r)
+	z.SetMaxBuf(limit)
+
+	var cur *commentState
+	var parents []int // The IDs of the latest comment at each depth above the current one
+	var title strings.Builder
+	inTitle := false
+
+	emit := func() error {
+		if cur == nil {
+			return nil
+		}
+		c := cur
+		cur = nil
+		c.flush()
+
+		posted, err := parseAgeTitle(c.ageTitle, s.location)
+		if err != nil {
+			if posted, err = parseRelativeAge(c.age.String(), retrieved.In(s.location)); err != nil {
+				return err
+			}
+		}
+
+		comment := c.comment
+		comment.Text = strings.Join(c.paragraphs, "\n\n")
+		comment.TimePosted = posted
+		comment.StoryID = storyID
+		comment.StoryTitle = title.String()
+		if !s.rawTitles {
+			comment.StoryTitle = normalizeTitle(comment.StoryTitle)
+		}
+
+		if comment.Depth < len(parents) {
+			parents = parents[:comment.Depth]
+		}
+		comment.ParentID = storyID
+		if len(parents) > 0 {
+			comment.ParentID = parents[len(parents)-1]
+		}
+		parents = append(parents, comment.ID)
+
+		return fn(comment)
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				if errors.Is(err, html.ErrBufferExceeded) {
+					return ErrCommentTooLarge
+				}
+				return err
+			}
+			return emit()
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, attrs := tagAttrs(z)
+
+			if name == "tr" && strings.Contains(attrs["class"], "comtr") {
+				if err := emit(); err != nil {
+					return err
+				}
+				id, err := strconv.Atoi(attrs["id"])
+				if err != nil {
+					return errors.New(errorMsg)
+				}
+				cur = &commentState{comment: Comment{ID: id}}
+				continue
+			}
+
+			if cur == nil {
+				if name == "a" && strings.Contains(attrs["class"], "titlelink") {
+					inTitle = true
+				}
+				continue
+			}
+
+			switch {
+			case cur.skipDivs > 0:
+				if name == "div" && tt == html.StartTagToken {
+					cur.skipDivs++
+				}
+			case cur.textSpans > 0:
+				switch name {
+				case "span":
+					if tt == html.StartTagToken {
+						cur.textSpans++
+					}
+				case "p":
+					cur.flush()
+				case "a":
+					cur.inLink = true
+					cur.linkHref = attrs["href"]
+					cur.link.Reset()
+				case "div":
+					if attrs["class"] == "reply" && tt == html.StartTagToken {
+						cur.skipDivs = 1
+					}
+				}
+			case cur.ageSpans > 0:
+				if name == "span" && tt == html.StartTagToken {
+					cur.ageSpans++
+				}
+			case name == "td" && strings.Contains(attrs["class"], "ind"):
+				if depth, err := strconv.Atoi(attrs["indent"]); err == nil {
+					cur.comment.Depth = depth
+				} else {
+					cur.expectSpacer = true
+				}
+			case name == "img" && cur.expectSpacer:
+				// Older markup only indents with a spacer image, 40 pixels per level
+				width, _ := strconv.Atoi(attrs["width"])
+				cur.comment.Depth = width / 40
+				cur.expectSpacer = false
+			case name == "a" && strings.Contains(attrs["class"], "hnuser"):
+				cur.inAuthor = true
+			case name == "span" && strings.Contains(attrs["class"], "commtext") && tt == html.StartTagToken:
+				cur.textSpans = 1
+			case name == "span" && strings.Contains(attrs["class"], "age") && tt == html.StartTagToken:
+				cur.ageTitle = attrs["title"]
+				cur.ageSpans = 1
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+
+			if cur == nil {
+				if tag == "a" {
+					inTitle = false
+				}
+				continue
+			}
+
+			switch {
+			case cur.skipDivs > 0:
+				if tag == "div" {
+					cur.skipDivs--
+				}
+			case cur.textSpans > 0:
+				switch tag {
+				case "span":
+					cur.textSpans--
+				case "a":
+					if cur.inLink {
+						// HN shortens long links with an ellipsis, so use the full target instead
+						if text := cur.link.String(); strings.HasSuffix(text, "...") {
+							cur.paragraph.WriteString(cur.linkHref)
+						} else {
+							cur.paragraph.WriteString(text)
+						}
+						cur.inLink = false
+					}
+				}
+			case cur.ageSpans > 0:
+				if tag == "span" {
+					cur.ageSpans--
+				}
+			case tag == "a":
+				cur.inAuthor = false
+			}
+
+		case html.TextToken:
+			text := z.Text()
+			switch {
+			case cur == nil:
+				if inTitle {
+					title.Write(text)
+				}
+			case cur.skipDivs > 0:
+			case cur.textSpans > 0:
+				cur.size += len(text)
+				if cur.size > limit {
+					return ErrCommentTooLarge
+				}
+				if cur.inLink {
+					cur.link.Write(text)
+				} else {
+					cur.paragraph.Write(text)
+				}
+			case cur.ageSpans > 0:
+				cur.age.Write(text)
+			case cur.inAuthor:
+				cur.comment.By += string(text)
+			}
+		}
+	}
+}
+
+// tagAttrs returns the current tag's name and attributes.
+func tagAttrs(z *html.Tokenizer) (string, map[string]string) {
+	name, more := z.TagName()
+	attrs := make(map[string]string)
+	for more {
+		var key, val []byte
+		key, val, more = z.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+
+	return string(name), attrs
+}
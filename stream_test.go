@@ -0,0 +1,124 @@
+package hnscraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+func TestStreamComments(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/item.html"), WithUTC())
+	item, err := s.ScrapeItem(28719320)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	want := flattenComments(item.Comments)
+
+	var got []Comment
+	err = s.StreamComments(context.Background(), 28719320, func(comment Comment) error {
+		got = append(got, comment)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatal("streamed ", len(got), " comments instead of ", len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.By != w.By || g.Text != w.Text || !g.TimePosted.Equal(w.TimePosted) ||
+			g.Depth != w.Depth || g.ParentID != w.ParentID || g.StoryID != w.StoryID || g.StoryTitle != w.StoryTitle {
+			t.Errorf("streamed %+v instead of %+v", g, w)
+		}
+	}
+}
+
+func TestStreamCommentsStop(t *testing.T) {
+	s := newTestScraper(t, serveFile("testdata/item.html"))
+	stop := errors.New("enough")
+
+	n := 0
+	err := s.StreamComments(context.Background(), 28719320, func(comment Comment) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Error("returned ", err, " after ", n, " comments instead of stopping at the first")
+	}
+}
+
+func TestStreamCommentsSizeLimit(t *testing.T) {
+	page := bigItemPage(t, 1, strings.Repeat("spam ", 1000))
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(page)
+	}, WithCommentSizeLimit(1024))
+
+	err := s.StreamComments(context.Background(), 28719320, func(comment Comment) error { return nil })
+	if !errors.Is(err, ErrCommentTooLarge) {
+		t.Error("returned ", err, " instead of ErrCommentTooLarge")
+	}
+}
+
+// bigItemPage builds an item page with n top-level comments of the given text, based on the item fixture.
+func bigItemPage(tb testing.TB, n int, text string) []byte {
+	fixture, err := os.ReadFile("testdata/item.html")
+	if err != nil {
+		tb.Fatal("error: ", err)
+	}
+	head := fixture[:bytes.Index(fixture, []byte("<tr class='athing comtr'"))]
+
+	var buf bytes.Buffer
+	buf.Write(head)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, `<tr class='athing comtr' id='%d'><td><table border='0'><tr><td class='ind' indent='%d'><img src="s.gif" height="1" width="0"></td>`+
+			`<td class="default"><div><span class="comhead"><a href="user?id=user%d" class="hnuser">user%d</a> `+
+			`<span class="age" title="2021-10-01T15:02:11 1633100531"><a href="item?id=%d">15 days ago</a></span></span></div><br>`+
+			`<div class="comment"><span class="commtext c00">%s<p>Comment number %d.</span>`+
+			`<div class='reply'><p><font size="1"><u><a href="reply?id=%d">reply</a></u></font></div></div></td></tr></table></td></tr>`+"\n",
+			30000000+i, i%3, i, i, 30000000+i, text, i, 30000000+i)
+	}
+	buf.WriteString("</table><br><br></td></tr></table></center></body></html>")
+
+	return buf.Bytes()
+}
+
+func BenchmarkCommentsDOM(b *testing.B) {
+	page := bigItemPage(b, 2000, "Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
+	s := NewScraper()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doc, err := htmlquery.Parse(bytes.NewReader(page))
+		if err != nil {
+			b.Fatal("error: ", err)
+		}
+		if _, err := s.parseItem(context.Background(), doc, time.Now()); err != nil {
+			b.Fatal("error: ", err)
+		}
+	}
+}
+
+func BenchmarkCommentsStream(b *testing.B) {
+	page := bigItemPage(b, 2000, "Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
+	s := NewScraper()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := s.streamComments(bytes.NewReader(page), 28719320, time.Now(), func(Comment) error { return nil })
+		if err != nil {
+			b.Fatal("error: ", err)
+		}
+	}
+}
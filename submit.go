@@ -0,0 +1,224 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// ErrPostingTooFast is returned when a submission or reply is made before WithPostInterval allows,
+// or HackerNews itself refuses it for coming too soon after the last one.
+var ErrPostingTooFast = errors.New("posting too fast")
+
+// ErrPostNotFound is returned by Submit and Reply when the post was sent, but its item ID couldn't be found afterwards
+// among the account's submissions or comments. The post may well have gone through, so it shouldn't be sent again.
+var ErrPostNotFound = errors.New("posted, but could not find the new item")
+
+// The default for WithPostInterval
+const defaultPostInterval = time.Minute
+
+// WithPostInterval spaces the submissions and replies a Scraper makes at least d apart, failing early ones
+// with ErrPostingTooFast instead of sending them. The default is one minute; HackerNews enforces its own,
+// often longer, limits on top.
+func WithPostInterval(d time.Duration) Option {
+	return func(s *Scraper) {
+		s.postInterval = d
+	}
+}
+
+// Submit submits a story as the logged-in account and returns its item ID.
+// Give either a link or text; text-only submissions become self posts such as Ask HN.
+// The new story is found among the account's submissions by its title and link, and ErrPostNotFound is returned
+// if it isn't there, ie. because HackerNews sent a duplicate link to the existing story.
+func (s *Scraper) Submit(ctx context.Context, title, link, text string) (int, error) {
+	if title == "" {
+		return 0, errors.New("submission must have a title")
+	}
+	form := url.Values{"title": {title}, "url": {link}, "text": {text}}
+	if err := s.submitForm(ctx, "submit", form); err != nil {
+		return 0, err
+	}
+
+	// HN redirects to /newest rather than the new item, so find it among the account's submissions. The newest one
+	// isn't necessarily it: HN may have sent a duplicate link to the existing story, or killed the new one
+	doc, err := s.fetch(ctx, s.baseURL+"submitted?id="+url.QueryEscape(s.username()))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	page, err := s.parsePage(ctx, doc, 1, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	for _, post := range page.Posts {
+		if post.RawTitle == strings.TrimSpace(title) && (link == "" || post.URL == link) {
+			return post.ID, nil
+		}
+	}
+
+	return 0, ErrPostNotFound
+}
+
+// Reply posts a comment replying to the post or comment as the logged-in account and returns its item ID.
+func (s *Scraper) Reply(ctx context.Context, parentID int, text string) (int, error) {
+	if parentID < 1 {
+		return 0, errors.New("item id must be a positive integer")
+	}
+	if strings.TrimSpace(text) == "" {
+		return 0, errors.New("reply must have text")
+	}
+	if err := s.submitForm(ctx, "reply?id="+strconv.Itoa(parentID), url.Values{"text": {text}}); err != nil {
+		return 0, err
+	}
+
+	// The account's newest comments lead its threads page, each linking to what it replies to.
+	// Matching on the parent keeps a concurrent reply elsewhere from being mistaken for this one
+	doc, err := s.fetch(ctx, s.baseURL+"threads?id="+url.QueryEscape(s.username()))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	retrieved := time.Now()
+	for _, node := range htmlquery.QuerySelectorAll(doc, commentRowsExpr) {
+		comment, err := s.getComment(node, retrieved)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrPostNotFound, stageError("comment", err))
+		}
+		if comment.By == s.username() && getParentID(node) == parentID {
+			return comment.ID, nil
+		}
+	}
+
+	return 0, ErrPostNotFound
+}
+
+var (
+	parentLinkExpr  = xpath.MustCompile("//span[contains(@class, 'navs')]/a[text()='parent']")
+	postFormExpr    = xpath.MustCompile("//form[@method='post']")
+	hiddenInputExpr = xpath.MustCompile("//input[@type='hidden']")
+)
+
+// getParentID returns the item ID a comment row's "parent" link points at, either an item page or,
+// for parents on the same page, an anchor. It is 0 if the row has no parent link.
+func getParentID(node *html.Node) int {
+	link := htmlquery.QuerySelector(node, parentLinkExpr)
+	if link == nil {
+		return 0
+	}
+
+	href := htmlquery.SelectAttr(link, "href")
+	href = strings.TrimPrefix(strings.TrimPrefix(href, "item?id="), "#")
+	id, _ := strconv.Atoi(href)
+
+	return id
+}
+
+// submitForm fills in the form on the page at path with the fields and submits it.
+// HackerNews protects its forms with one-off tokens (fnid on submissions, hmac on replies),
+// which are copied over from the form's hidden inputs.
+func (s *Scraper) submitForm(ctx context.Context, path string, fields url.Values) error {
+	if s.session == "" {
+		return ErrNotLoggedIn
+	}
+	ctx, cancel := s.operation(ctx)
+	defer cancel()
+
+	doc, err := s.fetch(ctx, s.baseURL+path)
+	if err != nil {
+		return err
+	}
+	if isNoprocrast(doc) {
+		return ErrNoprocrast
+	}
+	formNode := htmlquery.QuerySelector(doc, postFormExpr)
+	if formNode == nil {
+		return errors.New(errorMsg)
+	}
+
+	form := url.Values{}
+	for _, input := range htmlquery.QuerySelectorAll(formNode, hiddenInputExpr) {
+		form.Set(htmlquery.SelectAttr(input, "name"), htmlquery.SelectAttr(input, "value"))
+	}
+	for name, values := range fields {
+		form[name] = values
+	}
+	action := strings.TrimPrefix(htmlquery.SelectAttr(formNode, "action"), "/")
+	if action == "" || strings.Contains(action, "//") {
+		return errors.New(errorMsg)
+	}
+
+	// The POST goes through the same breaker and robots.txt check as any other request, but posting isn't idempotent,
+	// so it is never retried
+	var result *html.Node
+	err = s.guard(ctx, s.baseURL+action, func(ctx context.Context) (int, error) {
+		// The post slot is only claimed once there is something to post, so failing to load the form doesn't use it up
+		if err := s.reservePost(); err != nil {
+			return 0, err
+		}
+		if !s.spendRequest() {
+			return 1, ErrBudgetExceeded
+		}
+		if err := s.wait(ctx, s.interval); err != nil {
+			return 1, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+action, strings.NewReader(form.Encode()))
+		if err != nil {
+			return 1, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return 1, s.do(req, func(r io.Reader) (err error) {
+			result, err = htmlquery.Parse(r)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if body := htmlquery.QuerySelector(result, bodyExpr); body != nil &&
+		strings.Contains(htmlquery.InnerText(body), "posting too fast") {
+		return ErrPostingTooFast
+	}
+
+	return nil
+}
+
+// reservePost claims the next post slot, failing if the previous post was made less than the post interval ago.
+func (s *Scraper) reservePost() error {
+	interval := s.postInterval
+	if interval == 0 {
+		interval = defaultPostInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); !s.lastPost.IsZero() && now.Sub(s.lastPost) < interval {
+		return ErrPostingTooFast
+	}
+	s.lastPost = time.Now()
+
+	return nil
+}
+
+// username returns the logged-in account's name.
+func (s *Scraper) username() string {
+	return cookieUsername(s.session)
+}
+
+// cookieUsername returns the name of the account a session cookie logs in, which the cookie starts with, ie. "alice&...".
+func cookieUsername(cookie string) string {
+	if i := strings.Index(cookie, "&"); i >= 0 {
+		return cookie[:i]
+	}
+
+	return cookie
+}
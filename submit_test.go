@@ -0,0 +1,166 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitAndReply(t *testing.T) {
+	var mu sync.Mutex
+	posted := map[string]url.Values{}
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/submit":
+			fmt.Fprint(w, `<html><body><form action="/r" method="post"><input type="hidden" name="fnop" value="submit-page">`+
+				`<input type="hidden" name="fnid" value="fnid123"><input type="text" name="title"><input type="text" name="url">`+
+				`<textarea name="text"></textarea><input type="submit" value="submit"></form></body></html>`)
+		case "/reply":
+			fmt.Fprint(w, `<html><body><form action="comment" method="post"><input type="hidden" name="parent" value="`+
+				r.URL.Query().Get("id")+`"><input type="hidden" name="goto" value="item?id=1"><input type="hidden" name="hmac" value="hmac456">`+
+				`<textarea name="text"></textarea><input type="submit" value="reply"></form></body></html>`)
+		case "/r", "/comment":
+			if r.Method != http.MethodPost || r.ParseForm() != nil {
+				t.Error("submitted the form incorrectly")
+			}
+			mu.Lock()
+			posted[r.URL.Path] = r.PostForm
+			mu.Unlock()
+			fmt.Fprint(w, `<html><body>Thanks!</body></html>`)
+		case "/submitted":
+			// Another submission is listed before the new one
+			fmt.Fprint(w, listingPage([]int{28888050, 28888042, 28880000}, 1, ""))
+		case "/threads":
+			http.ServeFile(w, r, "testdata/item.html")
+		default:
+			http.NotFound(w, r)
+		}
+	}, WithSession("acme_jobs&secret"), WithPostInterval(time.Nanosecond))
+
+	id, err := s.Submit(context.Background(), "Post 28888042", "https://example.com/28888042", "")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if id != 28888042 {
+		t.Error("returned item ", id, " instead of the submission with the title and link")
+	}
+	if form := posted["/r"]; form.Get("fnid") != "fnid123" || form.Get("fnop") != "submit-page" ||
+		form.Get("title") != "Post 28888042" || form.Get("url") != "https://example.com/28888042" {
+		t.Error("submitted form ", form)
+	}
+
+	// A submission HN didn't list, ie. a duplicate link sent to the existing story, isn't mistaken for another
+	if id, err := s.Submit(context.Background(), "Post 28888042", "https://example.com/other", ""); !errors.Is(err, ErrPostNotFound) {
+		t.Error("returned item ", id, " and ", err, " for a submission that isn't listed")
+	}
+
+	id, err = s.Reply(context.Background(), 28719455, "Great work!")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if id != 28719470 {
+		t.Error("returned comment ", id, " instead of the account's reply to the parent")
+	}
+	if form := posted["/comment"]; form.Get("hmac") != "hmac456" || form.Get("parent") != "28719455" || form.Get("text") != "Great work!" {
+		t.Error("submitted reply form ", form)
+	}
+
+	// The account's other comments aren't mistaken for a reply that never showed up
+	if id, err := s.Reply(context.Background(), 28719520, "Me too"); !errors.Is(err, ErrPostNotFound) {
+		t.Error("returned comment ", id, " and ", err, " for a reply that isn't on the threads page")
+	}
+}
+
+func TestPostGuarded(t *testing.T) {
+	var posts int32
+	newScraper := func(robots string) *Scraper {
+		return newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			switch {
+			case r.URL.Path == "/robots.txt":
+				fmt.Fprint(w, "User-agent: *\nDisallow: "+robots+"\n")
+			case r.Method == http.MethodPost:
+				atomic.AddInt32(&posts, 1)
+				fmt.Fprint(w, `<html><body>Thanks!</body></html>`)
+			case r.URL.Path == "/reply":
+				fmt.Fprint(w, `<html><body><form action="comment" method="post"><input type="hidden" name="hmac" value="x">`+
+					`<textarea name="text"></textarea></form></body></html>`)
+			}
+		}, WithSession("alice&secret"), WithRobots(), WithPostInterval(time.Nanosecond))
+	}
+
+	if _, err := newScraper("/comment").Reply(context.Background(), 1, "Hello"); !errors.Is(err, ErrDisallowed) {
+		t.Error("returned ", err, " instead of ErrDisallowed")
+	}
+	if n := atomic.LoadInt32(&posts); n != 0 {
+		t.Error("posted ", n, " replies that robots.txt disallows")
+	}
+
+	// The reply goes through, but looking it up is disallowed, which mustn't look like the reply failed
+	if _, err := newScraper("/threads").Reply(context.Background(), 1, "Hello"); !errors.Is(err, ErrPostNotFound) {
+		t.Error("returned ", err, " instead of ErrPostNotFound")
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Error("posted ", n, " replies instead of 1")
+	}
+}
+
+func TestPostIntervalFailedForm(t *testing.T) {
+	var broken int32 = 1
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if atomic.LoadInt32(&broken) == 1 {
+			http.Error(w, "Sorry.", http.StatusInternalServerError)
+			return
+		}
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `<html><body>Thanks!</body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><form action="comment" method="post"><input type="hidden" name="hmac" value="x">`+
+			`<textarea name="text"></textarea></form></body></html>`)
+	}, WithSession("alice&secret"))
+
+	if err := s.submitForm(context.Background(), "reply?id=1", url.Values{"text": {"Hello"}}); err == nil {
+		t.Fatal("posted without a form")
+	}
+	atomic.StoreInt32(&broken, 0)
+	if err := s.submitForm(context.Background(), "reply?id=1", url.Values{"text": {"Hello"}}); err != nil {
+		t.Error("failing to load the form used up the post slot: ", err)
+	}
+}
+
+func TestPostInterval(t *testing.T) {
+	var posts int32
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.Method == http.MethodPost {
+			atomic.AddInt32(&posts, 1)
+			fmt.Fprint(w, `<html><body>You're posting too fast. Please slow down. Thanks.</body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><form action="comment" method="post"><input type="hidden" name="hmac" value="x">`+
+			`<textarea name="text"></textarea></form></body></html>`)
+	}, WithSession("alice&secret"))
+
+	// HackerNews refuses the first reply, and the Scraper doesn't even send the second
+	for i := 0; i < 2; i++ {
+		if _, err := s.Reply(context.Background(), 1, "Hello"); !errors.Is(err, ErrPostingTooFast) {
+			t.Error("returned ", err, " instead of ErrPostingTooFast")
+		}
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Error("posted ", n, " replies instead of 1")
+	}
+
+	if _, err := NewScraper().Submit(context.Background(), "Title", "https://example.com", ""); !errors.Is(err, ErrNotLoggedIn) {
+		t.Error("returned ", err, " instead of ErrNotLoggedIn")
+	}
+}
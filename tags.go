@@ -0,0 +1,71 @@
+package hnscraper
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// A Tagger attaches topic tags to posts using a taxonomy of patterns, ie. "rust|cargo" for the tag "rust".
+// A Tagger is safe for concurrent use, including adding patterns while tagging.
+type Tagger struct {
+	mu    sync.RWMutex
+	rules []tagRule
+}
+
+type tagRule struct {
+	re  *regexp.Regexp
+	tag string
+}
+
+// NewTagger creates a Tagger without any patterns.
+func NewTagger() *Tagger {
+	return &Tagger{}
+}
+
+// Add tags any text matching the pattern, a regular expression that is matched case-insensitively against whole words,
+// so "go" matches "Go 1.17" but not "Google". Several patterns can share a tag.
+func (t *Tagger) Add(pattern, tag string) error {
+	re, err := regexp.Compile(`(?i)\b(?:` + pattern + `)\b`)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rules = append(t.rules, tagRule{re: re, tag: tag})
+	return nil
+}
+
+// Tags returns the sorted tags whose patterns match any of the texts, such as a post's title and an item's text.
+func (t *Tagger) Tags(texts ...string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	matched := make(map[string]bool)
+	for _, rule := range t.rules {
+		if matched[rule.tag] {
+			continue
+		}
+		for _, text := range texts {
+			if rule.re.MatchString(text) {
+				matched[rule.tag] = true
+				break
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(matched))
+	for tag := range matched {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Tag returns a copy of the post with Post.Tags set from its title.
+func (t *Tagger) Tag(post Post) Post {
+	post.Tags = t.Tags(post.Title)
+	return post
+}
@@ -0,0 +1,41 @@
+package hnscraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagger(t *testing.T) {
+	tagger := NewTagger()
+	for pattern, tag := range map[string]string{
+		"rust|cargo":          "rust",
+		"go|golang":           "go",
+		`postgres(ql)?|mysql`: "databases",
+		"sqlite":              "databases",
+	} {
+		if err := tagger.Add(pattern, tag); err != nil {
+			t.Fatal("error: ", err)
+		}
+	}
+	if err := tagger.Add("(", "broken"); err == nil {
+		t.Error("accepted an invalid pattern")
+	}
+
+	cases := map[string]string{
+		"Rewriting our Go service in Rust":      "go rust",
+		"Google announces a new phone":          "",
+		"Cargo workspaces explained":            "rust",
+		"SQLite is not a toy database":          "databases",
+		"Migrating from MySQL to PostgreSQL":    "databases",
+		"Golang generics, one year on (sqlite)": "databases go",
+	}
+	for title, want := range cases {
+		if tags := tagger.Tag(Post{Title: title}).Tags; strings.Join(tags, " ") != want {
+			t.Errorf("tagged %q with %q instead of %q", title, tags, want)
+		}
+	}
+
+	if tags := tagger.Tags("Ask HN: What are you working on?", "Mostly cargo culting"); len(tags) != 1 || tags[0] != "rust" {
+		t.Error("tagged the item text with ", tags)
+	}
+}
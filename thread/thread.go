@@ -0,0 +1,111 @@
+// Package thread renders a scraped HackerNews discussion as a self-contained HTML or Markdown document for archiving.
+package thread
+
+import (
+	"bufio"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+// timeLayout is how timestamps are shown, in the time zone they were scraped in
+const timeLayout = "Jan 2, 2006 15:04 MST"
+
+var funcs = map[string]interface{}{
+	"time": func(t time.Time) string {
+		return t.Format(timeLayout)
+	},
+	"datetime": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	"item": itemURL,
+	"paragraphs": func(text string) []string {
+		return strings.Split(text, "\n\n")
+	},
+}
+
+func itemURL(id int) string {
+	return "https://news.ycombinator.com/item?id=" + strconv.Itoa(id)
+}
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(
+	`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Post.Title}}</title>
+<style>
+body { font-family: Verdana, Geneva, sans-serif; font-size: 10pt; max-width: 50em; margin: 2em auto; background: #f6f6ef; }
+.meta { color: #828282; font-size: 9pt; }
+.comment { margin: 1em 0 0 0; }
+.replies { margin-left: 1.5em; padding-left: 0.75em; border-left: 1px solid #d8d8d0; }
+</style></head><body>
+<h1><a href="{{.Post.URL}}">{{.Post.Title}}</a></h1>
+<p class="meta">{{.Post.Score}} points by {{.Post.By}} at <time datetime="{{datetime .Post.TimePosted}}">{{time .Post.TimePosted}}</time> | <a href="{{item .Post.ID}}">{{.Post.NumComments}} comments</a></p>
+{{range paragraphs .Text}}{{if .}}<p>{{.}}</p>
+{{end}}{{end}}<p class="meta">Archived at <time datetime="{{datetime .Retrieved}}">{{time .Retrieved}}</time></p>
+<hr>
+{{template "comments" .Comments}}</body></html>
+{{define "comments"}}{{range .}}<div class="comment" id="{{.ID}}">
+<p class="meta">{{if .By}}{{.By}}{{else}}[deleted]{{end}} at <time datetime="{{datetime .TimePosted}}">{{time .TimePosted}}</time> | <a href="{{item .ID}}">link</a></p>
+{{range paragraphs .Text}}{{if .}}<p>{{.}}</p>
+{{end}}{{end}}{{if .Replies}}<div class="replies">
+{{template "comments" .Replies}}</div>
+{{end}}</div>
+{{end}}{{end}}`))
+
+// HTML writes the item as a standalone HTML page, with replies indented under the comments they answer.
+func HTML(w io.Writer, item hnscraper.Item) error {
+	return htmlTemplate.Execute(w, item)
+}
+
+// markdownEscaper escapes the characters that would otherwise be read as Markdown formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`,
+)
+
+// Markdown writes the item as a Markdown document. Replies are nested as blockquotes, one level per depth.
+func Markdown(w io.Writer, item hnscraper.Item) error {
+	bw := bufio.NewWriter(w)
+	post := item.Post
+
+	fmt.Fprintf(bw, "# [%s](%s)\n\n", markdownEscaper.Replace(post.Title), post.URL)
+	fmt.Fprintf(bw, "%d points by %s at %s | [%d comments](%s)\n\n",
+		post.Score, markdownEscaper.Replace(post.By), post.TimePosted.Format(timeLayout), post.NumComments, itemURL(post.ID))
+	if item.Text != "" {
+		fmt.Fprintf(bw, "%s\n\n", markdownEscaper.Replace(item.Text))
+	}
+	fmt.Fprintf(bw, "_Archived at %s_\n\n---\n", item.Retrieved.Format(timeLayout))
+
+	writeMarkdownComments(bw, item.Comments, 1)
+
+	return bw.Flush()
+}
+
+func writeMarkdownComments(w *bufio.Writer, comments []hnscraper.Comment, level int) {
+	quote := strings.Repeat("> ", level)
+	blank := strings.TrimSpace(quote)
+	// A line at the parent's quote level ends the previous comment's blockquote
+	separator := strings.TrimSpace(strings.Repeat("> ", level-1))
+
+	for _, comment := range comments {
+		by := "\\[deleted\\]"
+		if comment.By != "" {
+			by = markdownEscaper.Replace(comment.By)
+		}
+
+		fmt.Fprintf(w, "%s\n%s**%s** at %s | [link](%s)\n",
+			separator, quote, by, comment.TimePosted.Format(timeLayout), itemURL(comment.ID))
+		for _, paragraph := range strings.Split(comment.Text, "\n\n") {
+			if paragraph == "" {
+				continue
+			}
+			// Every line of the paragraph has to stay inside the quote, ie. in code samples
+			paragraph = strings.ReplaceAll(markdownEscaper.Replace(paragraph), "\n", "\n"+quote)
+			fmt.Fprintf(w, "%s\n%s%s\n", blank, quote, paragraph)
+		}
+		writeMarkdownComments(w, comment.Replies, level+1)
+	}
+}
@@ -0,0 +1,88 @@
+package thread
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thetallpaul/hnscraper"
+)
+
+var posted = time.Date(2021, 10, 16, 9, 0, 0, 0, time.UTC)
+
+var item = hnscraper.Item{
+	Post: hnscraper.Post{ID: 1, Title: "Ask HN: <Rust> or Go?", Score: 42, By: "alice", URL: "item?id=1",
+		NumComments: 3, TimePosted: posted},
+	Text:      "Which should I learn first?\n\nI know C.",
+	Retrieved: posted.Add(3 * time.Hour),
+	Comments: []hnscraper.Comment{
+		{ID: 2, By: "bob", Text: "Go, it's *simple*.", TimePosted: posted.Add(time.Hour), Replies: []hnscraper.Comment{
+			{ID: 3, Depth: 1, Text: "Agreed.\n\nfunc main() {\n}", TimePosted: posted.Add(2 * time.Hour)},
+		}},
+		{ID: 4, By: "carol", Text: "Rust.", TimePosted: posted.Add(90 * time.Minute)},
+	},
+}
+
+func TestHTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := HTML(&out, item); err != nil {
+		t.Fatal("error: ", err)
+	}
+	page := out.String()
+
+	for _, want := range []string{
+		"<title>Ask HN: &lt;Rust&gt; or Go?</title>",
+		"<p>Which should I learn first?</p>\n<p>I know C.</p>",
+		`<time datetime="2021-10-16T10:00:00Z">Oct 16, 2021 10:00 UTC</time>`,
+		"[deleted]",
+		`<a href="https://news.ycombinator.com/item?id=4">link</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Error("rendered page is missing ", want)
+		}
+	}
+	// The reply is nested inside its parent, before the next top-level comment
+	reply, parentEnd := strings.Index(page, `id="3"`), strings.Index(page, `id="4"`)
+	if replies := strings.Index(page, `class="replies"`); replies < 0 || replies > reply || reply > parentEnd {
+		t.Error("didn't nest the reply under its parent")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	if err := Markdown(&out, item); err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	want := `# [Ask HN: \<Rust\> or Go?](item?id=1)
+
+42 points by alice at Oct 16, 2021 09:00 UTC | [3 comments](https://news.ycombinator.com/item?id=1)
+
+Which should I learn first?
+
+I know C.
+
+_Archived at Oct 16, 2021 12:00 UTC_
+
+---
+
+> **bob** at Oct 16, 2021 10:00 UTC | [link](https://news.ycombinator.com/item?id=2)
+>
+> Go, it's \*simple\*.
+>
+> > **\[deleted\]** at Oct 16, 2021 11:00 UTC | [link](https://news.ycombinator.com/item?id=3)
+> >
+> > Agreed.
+> >
+> > func main() {
+> > }
+
+> **carol** at Oct 16, 2021 10:30 UTC | [link](https://news.ycombinator.com/item?id=4)
+>
+> Rust.
+`
+	if out.String() != want {
+		t.Errorf("rendered markdown:\n%s", out.String())
+	}
+}
@@ -0,0 +1,67 @@
+package hnscraper
+
+import "context"
+
+// A Tracer starts spans covering the stages of a scrape, see WithTracer. It is shaped after OpenTelemetry's tracer
+// so an adapter to it is a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, hnscraper.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetString(key, value string) { s.SetAttributes(attribute.String(key, value)) }
+//	func (s otelSpan) SetInt(key string, value int) { s.SetAttributes(attribute.Int(key, value)) }
+//	func (s otelSpan) End(err error) {
+//		if err != nil {
+//			s.RecordError(err)
+//			s.SetStatus(codes.Error, err.Error())
+//		}
+//		s.Span.End()
+//	}
+type Tracer interface {
+	// Start starts a span as a child of any span in ctx, returning a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// A Span is a single traced stage of a scrape.
+type Span interface {
+	SetString(key, value string)  // Records a string attribute, ie. the URL being fetched
+	SetInt(key string, value int) // Records an integer attribute, ie. how many attempts a fetch took
+	End(err error)                // Ends the span, recording err if the stage failed
+}
+
+// The spans a Scraper starts, and their attributes:
+const (
+	SpanFetch = "hnscraper.fetch" // Retrieving a page, including retries; "url", "attempts"
+	SpanParse = "hnscraper.parse" // Parsing a retrieved page; "url", "bytes"
+	SpanRow   = "hnscraper.row"   // Extracting a single post from a listing or item page; "id"
+)
+
+// WithTracer traces the stages of every scrape with the tracer: fetching each page, parsing it,
+// and extracting each post from it. Without a tracer no spans are started, at no cost.
+func WithTracer(tracer Tracer) Option {
+	return func(s *Scraper) {
+		s.tracer = tracer
+	}
+}
+
+// noopSpan is handed out when there is no tracer, so call sites needn't check for one.
+type noopSpan struct{}
+
+func (noopSpan) SetString(key, value string)  {}
+func (noopSpan) SetInt(key string, value int) {}
+func (noopSpan) End(err error)                {}
+
+// startSpan starts a span with the Scraper's tracer, if it has one.
+func (s *Scraper) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if s.tracer == nil {
+		return ctx, noopSpan{}
+	}
+
+	return s.tracer.Start(ctx, name)
+}
@@ -0,0 +1,83 @@
+package hnscraper
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type spanKey struct{}
+
+// recordingTracer records every span it starts, along with the name of its parent span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name, parent string
+	attrs        map[string]interface{}
+	ended        bool
+	err          error
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordedSpan) SetString(key, value string)  { s.attrs[key] = value }
+func (s *recordedSpan) SetInt(key string, value int) { s.attrs[key] = value }
+func (s *recordedSpan) End(err error)                { s.ended, s.err = true, err }
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	s := newTestScraper(t, serveFile("testdata/news.html"), WithTracer(tracer))
+
+	page, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	counts := make(map[string]int)
+	for _, span := range tracer.spans {
+		counts[span.name]++
+		if !span.ended || span.err != nil {
+			t.Error("span ", span.name, " ended ", span.ended, " with ", span.err)
+		}
+		switch span.name {
+		case SpanFetch:
+			if span.attrs["attempts"] != 1 || span.attrs["url"] == "" {
+				t.Error("fetch span has attributes ", span.attrs)
+			}
+		case SpanRow:
+			if span.parent != SpanParse || span.attrs["id"] == 0 {
+				t.Error("row span under ", span.parent, " has attributes ", span.attrs)
+			}
+		}
+	}
+	if counts[SpanFetch] != 1 || counts[SpanParse] != 1 || counts[SpanRow] != len(page.Posts) {
+		t.Error("started spans ", counts, " for ", len(page.Posts), " posts")
+	}
+}
+
+func TestNoTracerAllocs(t *testing.T) {
+	s := NewScraper()
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		_, span := s.startSpan(ctx, SpanFetch)
+		span.SetString("url", "news")
+		span.SetInt("attempts", 1)
+		span.End(nil)
+	})
+	if allocs != 0 {
+		t.Error("allocated ", allocs, " times without a tracer")
+	}
+}
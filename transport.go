@@ -0,0 +1,73 @@
+package hnscraper
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// tuneTransport applies fn to the Scraper's own copy of its client's transport, cloning the client and
+// transport the first time so clients passed to WithHTTPClient, and http.DefaultTransport, are never modified.
+// Clients with a RoundTripper other than *http.Transport are left alone.
+func (s *Scraper) tuneTransport(fn func(t *http.Transport)) {
+	if !s.ownTransport {
+		base := http.DefaultTransport
+		if s.client.Transport != nil {
+			base = s.client.Transport
+		}
+		transport, ok := base.(*http.Transport)
+		if !ok {
+			s.logf("transport options ignored: client has a custom RoundTripper")
+			return
+		}
+
+		client := *s.client
+		client.Transport = transport.Clone()
+		s.client = &client
+		s.ownTransport = true
+	}
+
+	fn(s.client.Transport.(*http.Transport))
+}
+
+// WithMaxIdleConnsPerHost keeps up to n idle connections to HackerNews open for reuse.
+// Go's default of 2 is plenty for sequential scraping but too few for concurrent item scrapes.
+// Transport options apply to the client set by any earlier WithHTTPClient, which is copied rather than modified.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(s *Scraper) {
+		s.tuneTransport(func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+			if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+				t.MaxIdleConns = n
+			}
+		})
+	}
+}
+
+// WithIdleConnTimeout closes connections that have been idle for longer than d. Monitors that poll less often
+// than Go's default of 90 seconds can raise it to keep their connection warm between polls.
+// Transport options apply to the client set by any earlier WithHTTPClient, which is copied rather than modified.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(s *Scraper) {
+		s.tuneTransport(func(t *http.Transport) {
+			t.IdleConnTimeout = d
+		})
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2. It is enabled by default, letting concurrent requests share one connection;
+// disabling it can help with proxies that mishandle HTTP/2.
+// Transport options apply to the client set by any earlier WithHTTPClient, which is copied rather than modified.
+func WithHTTP2(enabled bool) Option {
+	return func(s *Scraper) {
+		s.tuneTransport(func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = enabled
+			if enabled {
+				t.TLSNextProto = nil
+			} else {
+				// A non-nil, empty map is how net/http is told not to negotiate HTTP/2
+				t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package hnscraper
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportOptions(t *testing.T) {
+	custom := &http.Transport{MaxIdleConnsPerHost: 1}
+	client := &http.Client{Transport: custom}
+	s := NewScraper(WithHTTPClient(client), WithMaxIdleConnsPerHost(8), WithIdleConnTimeout(5*time.Minute), WithHTTP2(false))
+
+	transport, ok := s.client.Transport.(*http.Transport)
+	if !ok || transport == custom {
+		t.Fatal("tuned the caller's transport instead of a copy")
+	}
+	if custom.MaxIdleConnsPerHost != 1 || client.Transport != custom {
+		t.Error("modified the caller's client")
+	}
+	if transport.MaxIdleConnsPerHost != 8 || transport.IdleConnTimeout != 5*time.Minute ||
+		transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("did not apply every option to the transport")
+	}
+
+	s = NewScraper(WithIdleConnTimeout(time.Minute))
+	if s.client.Transport == http.DefaultTransport || http.DefaultTransport.(*http.Transport).IdleConnTimeout == time.Minute {
+		t.Error("modified http.DefaultTransport")
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(serveFile("testdata/news.html"))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	s := NewScraper(WithMaxIdleConnsPerHost(4))
+	s.baseURL = server.URL + "/"
+	if _, err := s.ScrapeMultPages(1, 5); err != nil {
+		t.Fatal("error: ", err)
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Error("opened ", n, " connections for 5 sequential pages instead of reusing 1")
+	}
+}
@@ -0,0 +1,110 @@
+package hnscraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// ErrUserNotFound is returned when HackerNews has no user with the requested username.
+var ErrUserNotFound = errors.New("no such user")
+
+// A User is a HackerNews user's profile.
+type User struct {
+	Username string    // The user's username
+	Created  time.Time // When the account was created
+	Karma    int       // The user's karma
+	About    string    // The user's self-description, with paragraphs separated by blank lines
+}
+
+// AuthorInfo is what is known about a post's submitter, see pipeline.Authors.
+// The zero value means the post wasn't enriched.
+type AuthorInfo struct {
+	Karma   int       // The submitter's karma when their profile was scraped
+	Created time.Time // When the submitter's account was created
+}
+
+var (
+	userRowsExpr = xpath.MustCompile("//tr[contains(@class, 'athing')]/../tr")
+	userCellExpr = xpath.MustCompile("/td")
+)
+
+// ScrapeUser scrapes a user's profile.
+func ScrapeUser(username string) (User, error) {
+	return defaultScraper.ScrapeUser(username)
+}
+
+// ScrapeUser scrapes a user's profile, failing with ErrUserNotFound if there is no such user.
+func (s *Scraper) ScrapeUser(username string) (User, error) {
+	ctx, cancel := s.operation(context.Background())
+	defer cancel()
+
+	if username == "" {
+		return User{}, errors.New("username must not be empty")
+	}
+
+	doc, err := s.fetch(ctx, s.baseURL+"user?id="+url.QueryEscape(username))
+	if err != nil {
+		return User{}, err
+	}
+
+	return parseUser(doc, s.location)
+}
+
+// parseUser reads the profile's label and value rows, ie. "karma:" and "4821".
+func parseUser(doc *html.Node, loc *time.Location) (User, error) {
+	var user User
+
+	rows := htmlquery.QuerySelectorAll(doc, userRowsExpr)
+	// HN answers unknown users with a bare "No such user." page
+	if len(rows) == 0 {
+		return user, ErrUserNotFound
+	}
+
+	for _, row := range rows {
+		cells := htmlquery.QuerySelectorAll(row, userCellExpr)
+		if len(cells) != 2 {
+			continue
+		}
+		value := cells[1]
+
+		switch strings.TrimSpace(htmlquery.InnerText(cells[0])) {
+		case "user:":
+			user.Username = strings.TrimSpace(htmlquery.InnerText(value))
+			// The account's creation time is given exactly as a Unix timestamp
+			if unix, err := strconv.ParseInt(htmlquery.SelectAttr(value, "timestamp"), 10, 64); err == nil {
+				user.Created = time.Unix(unix, 0).In(loc)
+			}
+		case "created:":
+			if !user.Created.IsZero() {
+				continue
+			}
+			created, err := time.ParseInLocation("January 2, 2006", strings.TrimSpace(htmlquery.InnerText(value)), time.UTC)
+			if err != nil {
+				return user, errors.New(errorMsg)
+			}
+			user.Created = created.In(loc)
+		case "karma:":
+			karma, err := strconv.Atoi(nonDigitsRegexp.ReplaceAllLiteralString(htmlquery.InnerText(value), ""))
+			if err != nil {
+				return user, errors.New(errorMsg)
+			}
+			user.Karma = karma
+		case "about:":
+			user.About = nodeText(value)
+		}
+	}
+
+	if user.Username == "" {
+		return user, errors.New(errorMsg)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,41 @@
+package hnscraper
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScrapeUser(t *testing.T) {
+	var requested string
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Query().Get("id")
+		http.ServeFile(w, r, "testdata/user.html")
+	}, WithUTC())
+
+	user, err := s.ScrapeUser("alice")
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+	if requested != "alice" {
+		t.Error("requested the profile of ", requested)
+	}
+	if user.Username != "alice" || user.Karma != 4821 || !user.Created.Equal(time.Date(2016, 10, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parsed user incorrectly: %+v", user)
+	}
+	if user.About != "Builds scrapers.\n\nSay hi at https://example.com" {
+		t.Errorf("parsed about as %q", user.About)
+	}
+}
+
+func TestScrapeUserNotFound(t *testing.T) {
+	s := newTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("No such user."))
+	})
+
+	if _, err := s.ScrapeUser("nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Error("returned ", err, " instead of ErrUserNotFound")
+	}
+}
@@ -0,0 +1,141 @@
+package hnscraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const firebaseURL = "https://hacker-news.firebaseio.com/v0/"
+
+// A Mismatch is a field where a scraped post disagrees with the official API.
+type Mismatch struct {
+	ID      int    // The item ID of the post
+	Field   string // The name of the Post field that disagrees, ie. "Score"
+	Scraped string // The value scraped from the page
+	API     string // The value reported by the API
+}
+
+// A VerifyReport compares a scraped page against the official HackerNews API.
+type VerifyReport struct {
+	Page       int        // The number of the page that was checked
+	Checked    int        // How many posts were compared
+	Mismatches []Mismatch // Every disagreement, in page order
+	ScoreLag   float64    // The mean of scraped score minus API score, positive when the API lags behind the page
+	CommentLag float64    // The mean of scraped comments minus API comments
+}
+
+// A Verifier cross-checks scraped pages against the official HackerNews API,
+// to confirm the HTML parser still works and to measure how far the API lags behind the site.
+// The zero value is ready to use.
+type Verifier struct {
+	APIURL      string       // The root of the API, empty for the official Firebase API
+	Client      *http.Client // The client to query the API with, nil for a default client
+	Concurrency int          // How many items are requested at once, 0 for 8
+}
+
+type apiItem struct {
+	ID          int    `json:"id"`
+	By          string `json:"by"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Time        int64  `json:"time"`
+}
+
+// Verify compares every post on a scraped front page listing against the API's item data and top stories ordering.
+func (v *Verifier) Verify(ctx context.Context, page Page) (VerifyReport, error) {
+	report := VerifyReport{Page: page.Num}
+	concurrency := v.Concurrency
+	if concurrency == 0 {
+		concurrency = 8
+	}
+
+	var top []int
+	if err := v.get(ctx, "topstories.json", &top); err != nil {
+		return report, err
+	}
+	apiRanks := make(map[int]int, len(top))
+	for i, id := range top {
+		apiRanks[id] = i + 1
+	}
+
+	items := make([]apiItem, len(page.Posts))
+	errs := make([]error, len(page.Posts))
+	forEach(ctx, len(page.Posts), concurrency, 0, func(i int) {
+		errs[i] = v.get(ctx, "item/"+strconv.Itoa(page.Posts[i].ID)+".json", &items[i])
+	})
+
+	scoreLag, commentLag := 0, 0
+	for i, post := range page.Posts {
+		if errs[i] != nil {
+			return report, errs[i]
+		}
+		item := items[i]
+		mismatch := func(field string, scraped, api interface{}) {
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				ID: post.ID, Field: field, Scraped: fmt.Sprint(scraped), API: fmt.Sprint(api)})
+		}
+
+		// Self posts have no url in the API but link to themselves on the page
+		if item.URL != "" && item.URL != post.URL {
+			mismatch("URL", post.URL, item.URL)
+		}
+		if item.By != post.By {
+			mismatch("By", post.By, item.By)
+		}
+		if item.Score != post.Score {
+			mismatch("Score", post.Score, item.Score)
+		}
+		if item.Descendants != post.NumComments {
+			mismatch("NumComments", post.NumComments, item.Descendants)
+		}
+		if apiRank, ok := apiRanks[post.ID]; !ok {
+			mismatch("Rank", post.Rank, "absent")
+		} else if apiRank != post.Rank {
+			mismatch("Rank", post.Rank, apiRank)
+		}
+
+		report.Checked++
+		scoreLag += post.Score - item.Score
+		commentLag += post.NumComments - item.Descendants
+	}
+
+	if report.Checked > 0 {
+		report.ScoreLag = float64(scoreLag) / float64(report.Checked)
+		report.CommentLag = float64(commentLag) / float64(report.Checked)
+	}
+
+	return report, nil
+}
+
+func (v *Verifier) get(ctx context.Context, path string, result interface{}) error {
+	apiURL := v.APIURL
+	if apiURL == "" {
+		apiURL = firebaseURL
+	}
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, apiURL+path)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
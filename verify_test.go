@@ -0,0 +1,56 @@
+package hnscraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/topstories.json":
+			w.Write([]byte(`[28888002, 28888001, 28888003]`))
+		case "/item/28888001.json":
+			w.Write([]byte(`{"id": 28888001, "by": "alice", "score": 300, "descendants": 104, "title": "Show HN: A scraper", "url": "https://github.com/example/project", "type": "story"}`))
+		case "/item/28888002.json":
+			w.Write([]byte(`{"id": 28888002, "by": "bob", "score": 1, "descendants": 0, "title": "Ask HN: What are you working on?", "type": "story"}`))
+		case "/item/28888003.json":
+			w.Write([]byte(`{"id": 28888003, "by": "example", "score": 1, "title": "Example is hiring", "url": "https://www.ycombinator.com/companies/example/jobs", "type": "job"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	s := newTestScraper(t, serveFile("testdata/news.html"))
+	page, err := s.ScrapePage(1)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	report, err := (&Verifier{APIURL: api.URL + "/"}).Verify(context.Background(), page)
+	if err != nil {
+		t.Fatal("error: ", err)
+	}
+
+	want := []Mismatch{
+		{ID: 28888001, Field: "Score", Scraped: "312", API: "300"},
+		{ID: 28888001, Field: "Rank", Scraped: "1", API: "2"},
+		{ID: 28888002, Field: "Rank", Scraped: "2", API: "1"},
+		{ID: 28888003, Field: "By", Scraped: "", API: "example"},
+		{ID: 28888003, Field: "Score", Scraped: "0", API: "1"},
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatal("reported mismatches ", report.Mismatches, " instead of ", want)
+	}
+	for i := range want {
+		if report.Mismatches[i] != want[i] {
+			t.Error("reported mismatch ", report.Mismatches[i], " instead of ", want[i])
+		}
+	}
+	if report.Checked != 3 || report.ScoreLag != 11.0/3 {
+		t.Error("checked ", report.Checked, " posts with score lag ", report.ScoreLag)
+	}
+}
@@ -0,0 +1,42 @@
+package hnscraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// forEach calls fn for every index below n using the given number of workers,
+// starting at most one call per interval if interval is positive.
+// Indices that haven't started when ctx is done are still passed to fn, which is expected to check ctx itself.
+func forEach(ctx context.Context, n, workers int, interval time.Duration, fn func(i int)) {
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		if ticker != nil && i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+			}
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}